@@ -0,0 +1,127 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action is what an EventSource does with the outcome of a connection attempt.
+type Action int
+
+const (
+	// ActionAccept reads events from the response.
+	ActionAccept Action = iota
+
+	// ActionRetry discards the response, and reconnects according to the
+	// retry policy.
+	ActionRetry
+
+	// ActionFatal discards the response, and permanently closes the event
+	// source.
+	ActionFatal
+)
+
+// Classification is the result of a Classifier.
+type Classification struct {
+	Action Action
+
+	// RetryAfter is the minimum delay before the next connection attempt. It's
+	// only used with ActionRetry.
+	RetryAfter time.Duration
+
+	// Err describes the failure. With ActionRetry, it's passed to the retry
+	// policy; with ActionFatal, it's returned by Read. If nil, the transport
+	// error or a *StatusError is used. If it wraps a *StatusError, the
+	// beginning of the response body is read into it.
+	Err error
+}
+
+// Classifier decides what an EventSource does with the outcome of a
+// connection attempt. Exactly one of resp and err is non-nil. Classifiers
+// shouldn't read or close the response body.
+type Classifier func(resp *http.Response, err error) Classification
+
+// DefaultClassifier accepts 200 OK responses with a text/event-stream
+// Content-Type. It retries transport errors, 5xx responses, 408 Request
+// Timeout, and 429 Too Many Requests, honoring any Retry-After header. All
+// other responses, canceled contexts, and redirect errors are fatal; in
+// particular, 204 No Content closes the event source with ErrClosed.
+// Retry-After dates are relative to the system clock.
+func DefaultClassifier(resp *http.Response, err error) Classification {
+	return defaultClassify(resp, err, time.Now())
+}
+
+// NewDefaultClassifier returns a Classifier that behaves like
+// DefaultClassifier, but uses the clock to interpret Retry-After dates. It's
+// used when Config.Classify is nil.
+func NewDefaultClassifier(clock Clock) Classifier {
+	return func(resp *http.Response, err error) Classification {
+		return defaultClassify(resp, err, clock.Now())
+	}
+}
+
+func defaultClassify(resp *http.Response, err error, now time.Time) Classification {
+	switch {
+	case errors.Is(err, context.Canceled): // canceled contexts are fatal
+		return Classification{Action: ActionFatal, Err: err}
+
+	case errors.Is(err, ErrTooManyRedirects), errors.Is(err, ErrBodyNotRewindable):
+		return Classification{Action: ActionFatal, Err: err}
+
+	case err != nil: // other execution errors are assumed to be non-fatal
+		return Classification{Action: ActionRetry, Err: err}
+
+	case resp.StatusCode >= 500, // 5xx are assumed to be temporary
+		resp.StatusCode == http.StatusRequestTimeout,
+		resp.StatusCode == http.StatusTooManyRequests:
+		return Classification{
+			Action:     ActionRetry,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+			Err:        newStatusError(resp),
+		}
+
+	case resp.StatusCode == 204: // 204 No Content is assumed to be fatal
+		return Classification{Action: ActionFatal, Err: ErrClosed}
+
+	case resp.StatusCode == 200:
+		ct := resp.Header.Get("content-type")
+		mt, _, _ := mime.ParseMediaType(ct)
+		if mt != "text/event-stream" {
+			return Classification{Action: ActionFatal, Err: &ContentTypeError{ContentType: ct}}
+		}
+		return Classification{Action: ActionAccept}
+
+	default:
+		return Classification{Action: ActionFatal, Err: newStatusError(resp)}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date. It returns zero if the header is missing, invalid,
+// or in the past.
+func parseRetryAfter(s string, now time.Time) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(s); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,142 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDefaultClassifier(t *testing.T) {
+	t.Parallel()
+
+	response := func(code int, header ...string) *http.Response {
+		resp := &http.Response{StatusCode: code, Status: http.StatusText(code), Header: http.Header{}}
+		for i := 0; i < len(header); i += 2 {
+			resp.Header.Set(header[i], header[i+1])
+		}
+		return resp
+	}
+
+	for i, tt := range []struct {
+		resp       *http.Response
+		err        error
+		action     Action
+		retryAfter time.Duration
+	}{
+		{resp: response(200, "Content-Type", "text/event-stream"), action: ActionAccept},
+		{resp: response(200, "Content-Type", "text/event-stream; charset=utf-8"), action: ActionAccept},
+		{resp: response(200), action: ActionFatal},
+		{resp: response(204), action: ActionFatal},
+		{resp: response(401), action: ActionFatal},
+		{resp: response(404), action: ActionFatal},
+		{resp: response(408), action: ActionRetry},
+		{resp: response(429), action: ActionRetry},
+		{resp: response(429, "Retry-After", "120"), action: ActionRetry, retryAfter: 2 * time.Minute},
+		{resp: response(500), action: ActionRetry},
+		{resp: response(503, "Retry-After", "3"), action: ActionRetry, retryAfter: 3 * time.Second},
+		{err: errors.New("connection refused"), action: ActionRetry},
+		{err: context.Canceled, action: ActionFatal},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := DefaultClassifier(tt.resp, tt.err)
+			if want, have := tt.action, c.Action; want != have {
+				t.Errorf("Action: want %d, have %d", want, have)
+			}
+			if want, have := tt.retryAfter, c.RetryAfter; want != have {
+				t.Errorf("RetryAfter: want %v, have %v", want, have)
+			}
+			if c.Action != ActionAccept && c.Err == nil {
+				t.Errorf("Err: want non-nil, have nil")
+			}
+		})
+	}
+
+	if c := DefaultClassifier(response(204), nil); !errors.Is(c.Err, ErrClosed) {
+		t.Errorf("204 Err: want %v, have %v", ErrClosed, c.Err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+
+	for i, tt := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"-1", 0},
+		{"5", 5 * time.Second},
+		{" 5 ", 5 * time.Second},
+		{"Wed, 21 Oct 2015 07:28:30 GMT", 30 * time.Second},
+		{"Wed, 21 Oct 2015 07:27:00 GMT", 0},
+		{"soon", 0},
+	} {
+		if want, have := tt.want, parseRetryAfter(tt.in, now); want != have {
+			t.Errorf("%d. %q: want %v, have %v", i, tt.in, want, have)
+		}
+	}
+}
+
+func TestEventSourceClassify(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := testServer(func(w responseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(401)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("hello")})
+	})
+	defer server.Close()
+
+	var retries []Retry
+	es := NewConfig(Config{
+		Request: request(server.URL),
+		Retry:   time.Millisecond,
+		RetryPolicy: retryPolicyFunc(func(r Retry) (time.Duration, bool) {
+			retries = append(retries, r)
+			return 0, true
+		}),
+		Classify: func(resp *http.Response, err error) Classification {
+			if resp != nil && resp.StatusCode == 401 {
+				return Classification{Action: ActionRetry, RetryAfter: 20 * time.Millisecond}
+			}
+			return DefaultClassifier(resp, err)
+		},
+	})
+	defer es.Close()
+
+	begin := time.Now()
+	event, err := es.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "hello", string(event.Data); want != have {
+		t.Errorf("Data: want %q, have %q", want, have)
+	}
+	if want, have := 20*time.Millisecond, time.Since(begin); have < want {
+		t.Errorf("elapsed: want at least %v, have %v", want, have)
+	}
+	if want, have := 1, len(retries); want != have {
+		t.Fatalf("retries: want %d, have %d", want, have)
+	}
+	if want, have := 401, retries[0].StatusCode; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := 20*time.Millisecond, retries[0].RetryAfter; want != have {
+		t.Errorf("RetryAfter: want %v, have %v", want, have)
+	}
+	if retries[0].Err == nil {
+		t.Errorf("Err: want non-nil, have nil")
+	}
+}
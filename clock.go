@@ -0,0 +1,52 @@
+package eventsource
+
+import "time"
+
+// Clock provides the current time and timers to an EventSource, so that retry
+// waits, backoff, and idle timeouts can be driven deterministically in tests.
+// See package eventsourcetest for a fake implementation.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a timer that sends the current time on its channel
+	// after the delay.
+	NewTimer(d time.Duration) Timer
+
+	// AfterFunc returns a timer that calls f in its own goroutine after the
+	// delay. The channel of the timer is nil.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer models a [time.Timer].
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SystemClock is the Clock used by default, which uses the time package.
+type SystemClock struct{}
+
+// Now implements Clock.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer implements Clock.
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// AfterFunc implements Clock.
+func (SystemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
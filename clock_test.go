@@ -0,0 +1,180 @@
+package eventsource_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/peterbourgon/eventsource"
+	"github.com/peterbourgon/eventsource/eventsourcetest"
+)
+
+type readResult struct {
+	event eventsource.Event
+	err   error
+}
+
+func read(es *eventsource.EventSource) <-chan readResult {
+	c := make(chan readResult, 1)
+	go func() {
+		event, err := es.Read()
+		c <- readResult{event, err}
+	}()
+	return c
+}
+
+func TestClockRetry(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		eventsource.NewEncoder(w).Encode(eventsource.Event{Data: []byte("foo")})
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := eventsourcetest.NewClock(begin)
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	es := eventsource.NewConfig(eventsource.Config{
+		Request:     req,
+		Retry:       time.Minute,
+		RetryPolicy: eventsource.ExponentialBackoff{},
+		Clock:       clock,
+	})
+	defer es.Close()
+
+	result := read(es)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute - time.Second)
+	select {
+	case r := <-result:
+		t.Fatalf("Read returned early: %v", r.err)
+	default:
+	}
+	clock.Advance(time.Second)
+
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Minute)
+
+	r := <-result
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if want, have := begin.Add(3*time.Minute), r.event.Metadata.ReceivedAt; !want.Equal(have) {
+		t.Errorf("ReceivedAt: want %v, have %v", want, have)
+	}
+}
+
+func TestClockIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		eventsource.NewEncoder(w).Encode(eventsource.Event{ID: r.Header.Get("Last-Event-Id") + "x", Data: []byte("foo")})
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	clock := eventsourcetest.NewClock(time.Now())
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	es := eventsource.NewConfig(eventsource.Config{
+		Request:     req,
+		Retry:       time.Minute,
+		IdleTimeout: time.Hour,
+		Clock:       clock,
+	})
+	defer es.Close()
+
+	if _, err := es.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := read(es)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	r := <-result
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if want, have := "xx", r.event.ID; want != have {
+		t.Errorf("ID: want %q, have %q", want, have)
+	}
+	if want, have := 2, requests; want != have {
+		t.Errorf("requests: want %d, have %d", want, have)
+	}
+}
+
+type retryObserver struct {
+	eventsource.NopObserver
+	delays chan time.Duration
+}
+
+func (o retryObserver) OnRetry(_ int, delay time.Duration) {
+	o.delays <- delay
+}
+
+func TestClockRetryAfterDate(t *testing.T) {
+	t.Parallel()
+
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", begin.Add(time.Hour).Format(http.TimeFormat))
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		eventsource.NewEncoder(w).Encode(eventsource.Event{Data: []byte("foo")})
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	clock := eventsourcetest.NewClock(begin)
+	observer := retryObserver{delays: make(chan time.Duration, 1)}
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	es := eventsource.NewConfig(eventsource.Config{
+		Request:  req,
+		Retry:    time.Millisecond,
+		Clock:    clock,
+		Observer: observer,
+	})
+	defer es.Close()
+
+	result := read(es)
+
+	if want, have := time.Hour, <-observer.delays; want != have {
+		t.Errorf("delay: want %v, have %v", want, have)
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+
+	if r := <-result; r.err != nil {
+		t.Fatal(r.err)
+	}
+}
@@ -0,0 +1,107 @@
+package eventsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec encodes and decodes event payloads.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec is a Codec for JSON payloads, using package encoding/json.
+type JSONCodec struct{}
+
+// Marshal implements Codec.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// PayloadError is returned when the payload of an event can't be encoded or
+// decoded. It doesn't affect the underlying stream, so reading or writing can
+// continue.
+type PayloadError struct {
+	Event Event
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *PayloadError) Error() string {
+	return fmt.Sprintf("invalid %s event payload: %v", e.Event.Type, e.Err)
+}
+
+// Unwrap returns the error from the codec.
+func (e *PayloadError) Unwrap() error {
+	return e.Err
+}
+
+// TypedReader reads events from an EventReader, and decodes their data into
+// values of type T.
+type TypedReader[T any] struct {
+	r     EventReader
+	codec Codec
+}
+
+// NewTypedReader returns a TypedReader that reads from r, and decodes data with
+// the codec. If the codec is nil, JSONCodec is used.
+func NewTypedReader[T any](r EventReader, codec Codec) *TypedReader[T] {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &TypedReader[T]{r: r, codec: codec}
+}
+
+// Read the next event, and decode its data. Errors from the EventReader are
+// returned as is. If the data can't be decoded, the event is returned with a
+// *PayloadError.
+func (r *TypedReader[T]) Read(ctx context.Context) (T, Event, error) {
+	var v T
+
+	e, err := r.r.ReadContext(ctx)
+	if err != nil {
+		return v, e, err
+	}
+
+	if err := r.codec.Unmarshal(e.Data, &v); err != nil {
+		return v, e, &PayloadError{Event: e, Err: err}
+	}
+
+	return v, e, nil
+}
+
+// TypedWriter encodes values of type T as the data of events, and writes them
+// to an Encoder.
+type TypedWriter[T any] struct {
+	enc   *Encoder
+	codec Codec
+}
+
+// NewTypedWriter returns a TypedWriter that writes to enc, and encodes data
+// with the codec. If the codec is nil, JSONCodec is used.
+func NewTypedWriter[T any](enc *Encoder, codec Codec) *TypedWriter[T] {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &TypedWriter[T]{enc: enc, codec: codec}
+}
+
+// Write encodes v as the data of the event, and writes the event. Any data
+// already in the event is replaced. If v can't be encoded, nothing is written,
+// and a *PayloadError is returned.
+func (w *TypedWriter[T]) Write(v T, e Event) error {
+	data, err := w.codec.Marshal(v)
+	if err != nil {
+		return &PayloadError{Event: e, Err: err}
+	}
+
+	e.Data = data
+	return w.enc.Encode(e)
+}
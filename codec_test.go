@@ -0,0 +1,108 @@
+package eventsource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func TestTypedReaderWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewTypedWriter[point](NewEncoder(&buf), nil)
+	for i, p := range []point{{1, 2}, {3, 4}} {
+		if err := w.Write(p, Event{Type: "point", ID: strings.Repeat("x", i+1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.WriteString("event: point\ndata: {\n\n")
+	w.Write(point{5, 6}, Event{Type: "point"})
+
+	ctx := context.Background()
+	r := NewTypedReader[point](NewDecoder(&buf), nil)
+
+	for _, want := range []point{{1, 2}, {3, 4}} {
+		have, e, err := r.Read(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != have {
+			t.Errorf("want %+v, have %+v", want, have)
+		}
+		if want, have := "point", e.Type; want != have {
+			t.Errorf("Type: want %q, have %q", want, have)
+		}
+	}
+
+	_, e, err := r.Read(ctx)
+	var pe *PayloadError
+	if !errors.As(err, &pe) {
+		t.Fatalf("want *PayloadError, have %T: %v", err, err)
+	}
+	if want, have := "{", string(pe.Event.Data); want != have {
+		t.Errorf("Data: want %q, have %q", want, have)
+	}
+	if want, have := "{", string(e.Data); want != have {
+		t.Errorf("Data: want %q, have %q", want, have)
+	}
+
+	if have, _, err := r.Read(ctx); err != nil || have != (point{5, 6}) {
+		t.Errorf("after payload error: want %+v, have %+v (%v)", point{5, 6}, have, err)
+	}
+
+	if _, _, err := r.Read(ctx); !errors.Is(err, io.EOF) {
+		t.Errorf("want EOF, have %v", err)
+	}
+}
+
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("not a string")
+	}
+	return []byte(strings.ToUpper(s)), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v any) error {
+	*v.(*string) = strings.ToLower(string(data))
+	return nil
+}
+
+func TestTypedCodec(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := NewTypedWriter[string](NewEncoder(&buf), upperCodec{}).Write("hello", Event{}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "data: HELLO\n\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	have, _, err := NewTypedReader[string](NewDecoder(&buf), upperCodec{}).Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hello"; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	var pe *PayloadError
+	err = NewTypedWriter[any](NewEncoder(&buf), upperCodec{}).Write(1, Event{Type: "number"})
+	if !errors.As(err, &pe) {
+		t.Fatalf("want *PayloadError, have %T: %v", err, err)
+	}
+	if want, have := "invalid number event payload: not a string", err.Error(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
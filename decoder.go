@@ -3,22 +3,61 @@ package eventsource
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"unicode/utf8"
 )
 
+// ErrTooLarge indicates a line or an event that exceeds a limit set in the
+// DecoderConfig. It's returned wrapped with details of the limit.
+var ErrTooLarge = errors.New("too large")
+
 // A Decoder reads and decodes EventSource events from an input stream.
 type Decoder struct {
-	r *bufio.Reader
+	r       *bufio.Reader
+	config  DecoderConfig
+	maxLine int
 
 	checkedBOM bool
+	skipLF     bool // the last line ended with CR, which may be followed by LF
 }
 
-// NewDecoder returns a new decoder that reads from r.
+// DecoderConfig sets limits on the input of a Decoder, to protect against
+// misbehaving servers. A value of zero means no limit.
+type DecoderConfig struct {
+	// MaxLineSize is the maximum length of a line in bytes, not including the
+	// line terminator. If zero, and MaxDataSize is set, lines are limited to
+	// MaxDataSize plus the length of a "data: " prefix, so that a single line
+	// can't be buffered without bound.
+	MaxLineSize int
+
+	// MaxDataSize is the maximum size of the data of an event in bytes.
+	MaxDataSize int
+
+	// MaxFields is the maximum number of fields in an event, not including
+	// comments.
+	MaxFields int
+}
+
+// NewDecoder returns a new decoder that reads from r, without limits.
 func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderConfig(r, DecoderConfig{})
+}
+
+// NewDecoderConfig returns a new decoder that reads from r, with the limits
+// set in the config.
+func NewDecoderConfig(r io.Reader, config DecoderConfig) *Decoder {
+	maxLine := config.MaxLineSize
+	if maxLine <= 0 && config.MaxDataSize > 0 {
+		maxLine = config.MaxDataSize + len("data: ")
+	}
+
 	return &Decoder{
-		r: bufio.NewReader(r),
+		r:       bufio.NewReader(r),
+		config:  config,
+		maxLine: maxLine,
 	}
 }
 
@@ -38,29 +77,88 @@ func (d *Decoder) checkBOM() {
 
 var colon = []byte{':'}
 
-// ReadField reads a single line from the stream and parses it as a field. A
-// complete event is signalled by an empty key and value. The returned error
-// may either be an error from the stream, or an ErrInvalidEncoding if the
-// value is not valid UTF-8.
-func (d *Decoder) ReadField() (field string, value []byte, err error) {
-	if !d.checkedBOM {
-		d.checkBOM()
+// readLine reads a single line from the stream, without the line terminator,
+// which may be CRLF, LF, or a lone CR (§6). The LF following a CR isn't
+// known until more input is available, so it's skipped when reading the next
+// line, rather than waiting for it. A final line without a terminator is
+// returned at the end of the stream. A line longer than the limit is consumed,
+// and discarded with ErrTooLarge, without buffering more than the limit.
+func (d *Decoder) readLine() ([]byte, error) {
+	if d.skipLF {
+		d.skipLF = false
+		if b, err := d.r.Peek(1); err == nil && b[0] == '\n' {
+			d.r.Discard(1)
+		}
 	}
 
-	var buf []byte
+	var (
+		line    []byte
+		tooLong bool
+	)
+
+	appendLine := func(b []byte) {
+		if tooLong || d.maxLine > 0 && len(line)+len(b) > d.maxLine {
+			line, tooLong = nil, true
+			return
+		}
+		line = append(line, b...)
+	}
 
 	for {
-		line, isPrefix, err := d.r.ReadLine()
+		if d.r.Buffered() == 0 {
+			if _, err := d.r.Peek(1); err != nil {
+				if errors.Is(err, io.EOF) && (len(line) > 0 || tooLong) {
+					break
+				}
+				return nil, err
+			}
+		}
 
-		if err != nil {
-			return "", nil, err
+		buf, _ := d.r.Peek(d.r.Buffered())
+
+		i := bytes.IndexAny(buf, "\r\n")
+		if i < 0 {
+			appendLine(buf)
+			d.r.Discard(len(buf))
+			continue
 		}
 
-		buf = append(buf, line...)
+		appendLine(buf[:i])
 
-		if !isPrefix {
-			break
+		n := i + 1
+		if buf[i] == '\r' {
+			switch {
+			case n == len(buf):
+				d.skipLF = true
+			case buf[n] == '\n':
+				n++
+			}
 		}
+
+		d.r.Discard(n)
+		break
+	}
+
+	if tooLong {
+		return nil, fmt.Errorf("%w: line longer than %d bytes", ErrTooLarge, d.maxLine)
+	}
+
+	return line, nil
+}
+
+// ReadField reads a single line from the stream and parses it as a field. A
+// complete event is signalled by an empty key and value. The returned error
+// may either be an error from the stream, an ErrInvalidEncoding if the value
+// is not valid UTF-8, or an error wrapping ErrTooLarge if the line is too
+// long.
+func (d *Decoder) ReadField() (field string, value []byte, err error) {
+	if !d.checkedBOM {
+		d.checkBOM()
+	}
+
+	buf, err := d.readLine()
+	if err != nil {
+		return "", nil, err
 	}
 
 	if len(buf) == 0 {
@@ -84,14 +182,26 @@ func (d *Decoder) ReadField() (field string, value []byte, err error) {
 }
 
 // Decode reads the next event from its input and stores it in the provided
-// Event pointer.
+// Event pointer. If the event exceeds a limit set in the DecoderConfig, the
+// rest of the event is skipped, so that the next call decodes the next event,
+// and an error wrapping ErrTooLarge is returned.
 func (d *Decoder) Decode(e *Event) error {
-	var wroteData bool
+	var (
+		wroteData bool
+		fields    int
+		tooLarge  error
+	)
 
 	e.Type = "message" // set default event type
 
 	for {
 		field, value, err := d.ReadField()
+		if errors.Is(err, ErrTooLarge) {
+			if tooLarge == nil {
+				tooLarge = err
+			}
+			continue
+		}
 		if err != nil {
 			return fmt.Errorf("read field: %w", err)
 		}
@@ -100,6 +210,15 @@ func (d *Decoder) Decode(e *Event) error {
 			break
 		}
 
+		if tooLarge != nil || len(field) == 0 { // skipping to the end of the event, or a comment
+			continue
+		}
+
+		if fields++; d.config.MaxFields > 0 && fields > d.config.MaxFields {
+			tooLarge = fmt.Errorf("%w: more than %d fields", ErrTooLarge, d.config.MaxFields)
+			continue
+		}
+
 		switch field {
 		case "id":
 			e.ID = string(value)
@@ -114,6 +233,14 @@ func (d *Decoder) Decode(e *Event) error {
 			e.Type = string(value)
 
 		case "data":
+			size := len(e.Data) + len(value)
+			if wroteData {
+				size++ // newline
+			}
+			if d.config.MaxDataSize > 0 && size > d.config.MaxDataSize {
+				tooLarge = fmt.Errorf("%w: data larger than %d bytes", ErrTooLarge, d.config.MaxDataSize)
+				continue
+			}
 			if wroteData {
 				e.Data = append(e.Data, '\n')
 			} else {
@@ -123,5 +250,21 @@ func (d *Decoder) Decode(e *Event) error {
 		}
 	}
 
-	return nil
+	return tooLarge
+}
+
+// ReadContext decodes the next event, so that a Decoder can be used as an
+// EventReader. The context is checked before decoding, but can't interrupt a
+// blocked read from the underlying stream.
+func (d *Decoder) ReadContext(ctx context.Context) (Event, error) {
+	if err := ctx.Err(); err != nil {
+		return Event{}, err
+	}
+
+	var e Event
+	if err := d.Decode(&e); err != nil {
+		return Event{}, err
+	}
+
+	return e, nil
 }
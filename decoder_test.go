@@ -3,9 +3,13 @@ package eventsource
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 var longline = string(bytes.Repeat([]byte{'a'}, 4096))
@@ -75,3 +79,149 @@ func TestDecoderDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestDecoderLineTerminators(t *testing.T) {
+	t.Parallel()
+
+	want := []Event{
+		{ID: "1", Data: []byte("foo\nbar")},
+		{ID: "2", Data: []byte("baz")},
+		{Data: []byte(longline)},
+	}
+
+	for name, in := range map[string]string{
+		"LF":    "id: 1\ndata: foo\ndata: bar\n\nid: 2\ndata: baz\n\ndata: " + longline + "\n\n",
+		"CRLF":  "id: 1\r\ndata: foo\r\ndata: bar\r\n\r\nid: 2\r\ndata: baz\r\n\r\ndata: " + longline + "\r\n\r\n",
+		"CR":    "id: 1\rdata: foo\rdata: bar\r\rid: 2\rdata: baz\r\rdata: " + longline + "\r\r",
+		"mixed": "id: 1\rdata: foo\r\ndata: bar\n\r\nid: 2\rdata: baz\n\rdata: " + longline + "\r\n\n",
+	} {
+		for _, split := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/split=%v", name, split), func(t *testing.T) {
+				var r io.Reader = strings.NewReader(in)
+				if split {
+					r = iotest.OneByteReader(r)
+				}
+				dec := NewDecoder(r)
+
+				for i := range want {
+					var event Event
+					if err := dec.Decode(&event); err != nil {
+						t.Fatalf("%d: %v", i, err)
+					}
+					if want, have := want[i].ID, event.ID; want != have {
+						t.Errorf("%d: ID: want %q, have %q", i, want, have)
+					}
+					if want, have := want[i].Data, event.Data; !bytes.Equal(want, have) {
+						t.Errorf("%d: Data: want %d bytes, have %d bytes", i, len(want), len(have))
+					}
+				}
+
+				var event Event
+				if err := dec.Decode(&event); !errors.Is(err, io.EOF) {
+					t.Errorf("want EOF, have %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestDecoderCRDoesNotBlock(t *testing.T) {
+	t.Parallel()
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	dec := NewDecoder(pr)
+	go pw.Write([]byte("data: foo\r\r"))
+
+	var event Event
+	if err := dec.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "foo", string(event.Data); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	go pw.Write([]byte("\ndata: bar\r\n\r\n"))
+
+	event = Event{}
+	if err := dec.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "bar", string(event.Data); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestDecoderLimits(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		config DecoderConfig
+		in     string
+	}{
+		"line":    {DecoderConfig{MaxLineSize: 16}, "data: foo\ndata: " + longline + "\ndata: bar\n\n"},
+		"line/CR": {DecoderConfig{MaxLineSize: 16}, "data: foo\rdata: " + longline + "\rdata: bar\r\r"},
+		"data":    {DecoderConfig{MaxDataSize: 16}, "data: foo\ndata: 0123456789abcdef\ndata: bar\n\n"},
+		"fields":  {DecoderConfig{MaxFields: 2}, "event: foo\n: comment\ndata: foo\nid: 1\ndata: bar\n\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoderConfig(strings.NewReader(tt.in+"data: ok\n\n"), tt.config)
+
+			var event Event
+			if err := dec.Decode(&event); !errors.Is(err, ErrTooLarge) {
+				t.Fatalf("want ErrTooLarge, have %v", err)
+			}
+
+			event = Event{}
+			if err := dec.Decode(&event); err != nil {
+				t.Fatal(err)
+			}
+			if want, have := "ok", string(event.Data); want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestDecoderLimitsNotExceeded(t *testing.T) {
+	t.Parallel()
+
+	config := DecoderConfig{MaxLineSize: 16, MaxDataSize: 7, MaxFields: 3}
+	dec := NewDecoderConfig(strings.NewReader("id: 1\n: a comment\ndata: foo\ndata: bar\n\n"), config)
+
+	var event Event
+	if err := dec.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "foo\nbar", string(event.Data); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestDecoderMaxDataSizeBoundsLines(t *testing.T) {
+	t.Parallel()
+
+	in := "data: " + strings.Repeat("x", 1<<20) + "\n\ndata: ok\n\n"
+	dec := NewDecoderConfig(strings.NewReader(in), DecoderConfig{MaxDataSize: 16})
+
+	var event Event
+	err := dec.Decode(&event)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("want ErrTooLarge, have %v", err)
+	}
+	if want, have := "too large: line longer than 22 bytes", err.Error(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if len(event.Data) > 0 {
+		t.Errorf("Data: want none, have %d bytes", len(event.Data))
+	}
+
+	event = Event{}
+	if err := dec.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "ok", string(event.Data); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,76 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxErrorBody is the maximum number of bytes of a response body kept by a
+// StatusError.
+const maxErrorBody = 1024
+
+// StatusError describes an unsuccessful response to a connection attempt.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+
+	// Body is the beginning of the response body, truncated to 1 KiB, which
+	// often explains the failure.
+	Body []byte
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+	}
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("endpoint returned status %s", e.Status)
+	}
+	return fmt.Sprintf("endpoint returned status %s: %q", e.Status, e.Body)
+}
+
+// readBody reads the beginning of the response body into the StatusError
+// wrapped by err, if any.
+func readBody(err error, resp *http.Response) {
+	var se *StatusError
+	if !errors.As(err, &se) || se.Body != nil {
+		return
+	}
+	se.Body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+}
+
+// ContentTypeError describes a successful response to a connection attempt
+// that isn't an event stream.
+type ContentTypeError struct {
+	ContentType string
+}
+
+// Error implements the error interface.
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("invalid response Content-Type (%s)", e.ContentType)
+}
+
+// RetriesExhaustedError is returned by Read when the RetryPolicy gives up.
+type RetriesExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+// Error implements the error interface.
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("gave up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the most recent failure.
+func (e *RetriesExhaustedError) Unwrap() error {
+	return e.Err
+}
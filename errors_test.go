@@ -0,0 +1,100 @@
+package eventsource
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-Reason", "token expired")
+			w.WriteHeader(403)
+			w.Write([]byte(strings.Repeat("x", 2*maxErrorBody)))
+		}),
+	)
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	defer es.Close()
+
+	_, err := es.Read()
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("want *StatusError, have %T: %v", err, err)
+	}
+	if want, have := 403, se.StatusCode; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := "token expired", se.Header.Get("X-Reason"); want != have {
+		t.Errorf("Header: want %q, have %q", want, have)
+	}
+	if want, have := maxErrorBody, len(se.Body); want != have {
+		t.Errorf("len(Body): want %d, have %d", want, have)
+	}
+}
+
+func TestContentTypeError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(200)
+		}),
+	)
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	defer es.Close()
+
+	_, err := es.Read()
+	var ce *ContentTypeError
+	if !errors.As(err, &ce) {
+		t.Fatalf("want *ContentTypeError, have %T: %v", err, err)
+	}
+	if want, have := "text/html", ce.ContentType; want != have {
+		t.Errorf("ContentType: want %q, have %q", want, have)
+	}
+}
+
+func TestRetriesExhaustedStatusError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(503)
+			w.Write([]byte("maintenance"))
+		}),
+	)
+	defer server.Close()
+
+	es := NewConfig(Config{
+		Request:     request(server.URL),
+		Retry:       time.Millisecond,
+		RetryPolicy: ConstantBackoff{MaxAttempts: 2},
+	})
+	defer es.Close()
+
+	_, err := es.Read()
+	var exhausted *RetriesExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("want *RetriesExhaustedError, have %T: %v", err, err)
+	}
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("want *StatusError, have %T: %v", err, err)
+	}
+	if want, have := 503, se.StatusCode; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := "maintenance", string(se.Body); want != have {
+		t.Errorf("Body: want %q, have %q", want, have)
+	}
+}
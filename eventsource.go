@@ -7,9 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,6 +21,14 @@ var (
 
 	// ErrInvalidEncoding indicates invalid UTF-8 event data.
 	ErrInvalidEncoding = errors.New("invalid UTF-8 sequence")
+
+	// ErrBodyNotRewindable indicates a request body that can't be sent again
+	// when reconnecting, because the request has no GetBody function.
+	ErrBodyNotRewindable = errors.New("request body not rewindable")
+
+	// ErrIdleTimeout indicates a connection that was closed because nothing
+	// was received within the idle timeout.
+	ErrIdleTimeout = errors.New("idle timeout")
 )
 
 // Event can be written to an event stream, and read from an event source.
@@ -28,17 +38,79 @@ type Event struct {
 	Retry   string
 	Data    []byte
 	ResetID bool
+
+	// Metadata is set on events returned by an EventSource, and ignored by
+	// Encoder. It differs between events that are otherwise equal, so clear
+	// it before comparing events with reflect.DeepEqual.
+	Metadata Metadata
+}
+
+// Metadata describes how an event was received by an EventSource, like the
+// lastEventId and origin attributes of a MessageEvent in the browser.
+type Metadata struct {
+	// LastEventID is the last event ID of the stream after this event, which
+	// is the ID of the event if it has one, or else that of the last event
+	// returned before it; events that aren't returned, such as events without
+	// data and duplicates, don't change it. Unless commits are manual, it's
+	// the ID the stream resumes from if the connection is lost after this
+	// event.
+	LastEventID string
+
+	// Connection is the sequence number of the connection the event was
+	// received on, starting at 1, and increasing with every reconnect.
+	Connection int64
+
+	// URL is the URL the connection was made to, after following any
+	// redirects, and Origin is the scheme and host of that URL.
+	URL    string
+	Origin string
+
+	// ReceivedAt is the time the event was received.
+	ReceivedAt time.Time
 }
 
 // EventSource consumes server sent events over HTTP with automatic recovery.
+//
+// Read and ReadContext should be called from a single goroutine. Close, Done,
+// and Err are safe to call from any goroutine.
 type EventSource struct {
-	client      HTTPClient
-	request     *http.Request
-	retry       time.Duration
-	err         error
-	r           io.ReadCloser
-	dec         *Decoder
-	lastEventID string
+	client       HTTPClient
+	endpoints    []func(ctx context.Context, lastEventID string) (*http.Request, error)
+	endpoint     atomic.Int32
+	nextEndpoint int
+	fatal        []bool // endpoints that failed fatally since the last connection
+	failover     Failover
+	maxRedirects int
+	moved        map[string]*url.URL
+	url          atomic.Pointer[url.URL]
+	ctx          context.Context
+	cancel       context.CancelCauseFunc
+	retry        time.Duration
+	policy       RetryPolicy
+	failures     int
+	failedAt     time.Time
+	lastErr      error
+	lastStatus   int
+	retryAfter   time.Duration
+	classifier   Classifier
+	observer     Observer
+	clock        Clock
+	stateEvents  bool
+	state        atomic.Int32
+	idleTimeout  time.Duration
+	decoder      DecoderConfig
+	r            io.Reader
+	closeConn    context.CancelFunc
+	idle         *idleReader
+	dec          *Decoder
+	idMtx        sync.Mutex
+	lastEventID  string
+	store        LastEventIDStore
+	manual       bool
+	seq          *sequencer
+	pending      *Event
+	meta         Metadata
+	stats        stats
 }
 
 // New calls NewConfig with the provided request and retry interval, and a
@@ -55,6 +127,99 @@ type Config struct {
 	Client  HTTPClient
 	Request *http.Request
 	Retry   time.Duration
+
+	// NewRequest, if set, is called to create the request for every
+	// connection attempt, instead of reusing Request. It's given the context
+	// for the attempt, and the ID of the last event received, if any. The
+	// Accept, Cache-Control, and Last-Event-Id headers are set on the returned
+	// request. Use it to refresh credentials, rotate endpoints, or add query
+	// parameters on every reconnect. If it returns an error, the error is
+	// classified like a transport error, so that it's retried according to
+	// the retry policy by default; ErrBodyNotRewindable is fatal.
+	NewRequest func(ctx context.Context, lastEventID string) (*http.Request, error)
+
+	// Endpoints, if not empty, is used instead of Request, to fail over
+	// between multiple endpoints serving the same event stream, for example
+	// in different regions. Each is used as a template in the same way as
+	// Request, and the last event ID is carried across endpoints. A response
+	// classified as fatal, other than context.Canceled, only closes the event
+	// source once every endpoint has failed fatally since the last successful
+	// connection; until then, it counts as a failed attempt, and the next
+	// endpoint is tried. It's ignored if NewRequest is set.
+	Endpoints []*http.Request
+
+	// Failover is the strategy used to choose between Endpoints.
+	Failover Failover
+
+	// MaxRedirects limits the number of redirects followed by each connection
+	// attempt; exceeding it is fatal. After a permanent redirect (301 or 308),
+	// subsequent connection attempts go directly to the new URL. If zero, 10
+	// redirects are allowed; if negative, redirects aren't followed, and the
+	// redirect response is passed to the classifier, which treats it as fatal
+	// by default. If Client is an *http.Client, a copy that doesn't follow
+	// redirects by itself is used, so its CheckRedirect function is ignored.
+	MaxRedirects int
+
+	// RetryPolicy decides how long to wait before each reconnect attempt, and
+	// when to give up. If nil, ConstantBackoff is used, which retries forever
+	// at the Retry interval, or the interval most recently sent by the server.
+	RetryPolicy RetryPolicy
+
+	// Classify decides whether each connection attempt is accepted, retried,
+	// or fatal. If nil, DefaultClassifier is used, with Retry-After dates
+	// interpreted according to Clock.
+	Classify Classifier
+
+	// Observer is notified about connection attempts, disconnects, retries,
+	// and dropped events. If nil, NopObserver is used.
+	Observer Observer
+
+	// IdleTimeout, if greater than zero, is the maximum time to wait for any
+	// data from an open connection, including comments, which servers may
+	// send as heartbeats. If it elapses, the connection is closed and
+	// reestablished as if it was lost, and observers are notified with
+	// ErrIdleTimeout. The timeout only runs while Read is waiting for an
+	// event, so time spent by the caller between calls to Read doesn't count.
+	IdleTimeout time.Duration
+
+	// Decoder sets limits on the size of lines and events received from the
+	// server. Events that exceed a limit are dropped, and observers are
+	// notified with an error wrapping ErrTooLarge.
+	Decoder DecoderConfig
+
+	// LastEventIDStore, if set, persists the last event ID across process
+	// restarts. The stored ID is loaded when the event source is created, and
+	// used for the first connection; it's saved whenever Read returns an event
+	// that changes the last event ID. If loading or saving fails, the event
+	// source is closed with that error.
+	LastEventIDStore LastEventIDStore
+
+	// ManualCommit, if true, means the last event ID only advances when an
+	// event is passed to Commit, rather than whenever Read returns an event.
+	// After a reconnect, the server resumes from the last committed event, so
+	// events that were read but not committed are delivered again, giving
+	// at-least-once semantics.
+	ManualCommit bool
+
+	// Sequencing, if set, suppresses duplicate events, and reports gaps in
+	// event IDs with a *GapError.
+	Sequencing *Sequencing
+
+	// StateEvents, if true, makes Read return synthetic events with type
+	// EventTypeOpen after every successful connection, and EventTypeError
+	// whenever an open connection is lost, mirroring the open and error
+	// events of the EventSource spec.
+	StateEvents bool
+
+	// Clock provides the current time and timers for retry waits, backoff,
+	// idle timeouts, and event metadata. If nil, SystemClock is used.
+	Clock Clock
+
+	// Context bounds the lifetime of the event source. When it's done, any
+	// in-flight request, read, or retry wait is aborted, and the event source
+	// is permanently closed with the context's error. If nil, the context of
+	// Request is used, or context.Background if Request is nil.
+	Context context.Context
 }
 
 // HTTPClient models an [http.Client].
@@ -63,119 +228,560 @@ type HTTPClient interface {
 }
 
 // NewConfig prepares an EventSource client. The connection is automatically
-// managed, using req to connect, and retrying from recoverable errors after
-// waiting the provided retry duration.
+// managed, using the request (or the request factory, or the endpoints) to
+// connect, and retrying from recoverable errors according to the retry
+// policy. One of Request, NewRequest, or Endpoints must be set. If a request
+// has a body without a GetBody function, so that it can't be sent again when
+// reconnecting, the event source is closed with ErrBodyNotRewindable.
 func NewConfig(config Config) *EventSource {
 	if config.Client == nil {
 		config.Client = http.DefaultClient
 	}
 
+	if c, ok := config.Client.(*http.Client); ok {
+		config.Client = noFollow(c)
+	}
+
+	if config.MaxRedirects == 0 {
+		config.MaxRedirects = defaultMaxRedirects
+	}
+
 	if config.Retry <= 0 {
 		config.Retry = time.Second
 	}
 
-	config.Request.Header.Set("Accept", "text/event-stream")
-	config.Request.Header.Set("Cache-Control", "no-cache")
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = ConstantBackoff{}
+	}
+
+	if config.Observer == nil {
+		config.Observer = NopObserver{}
+	}
+
+	if config.Clock == nil {
+		config.Clock = SystemClock{}
+	}
+
+	if config.Classify == nil {
+		config.Classify = NewDefaultClassifier(config.Clock)
+	}
+
+	if config.Context == nil {
+		if config.Request != nil {
+			config.Context = config.Request.Context()
+		} else {
+			config.Context = context.Background()
+		}
+	}
+
+	var (
+		templates []*http.Request
+		endpoints []func(context.Context, string) (*http.Request, error)
+	)
+	switch {
+	case config.NewRequest != nil:
+		endpoints = append(endpoints, config.NewRequest)
+	case len(config.Endpoints) > 0:
+		templates = config.Endpoints
+	default:
+		templates = []*http.Request{config.Request}
+	}
+	for _, req := range templates {
+		endpoints = append(endpoints, requestTemplate(req))
+	}
+
+	ctx, cancel := context.WithCancelCause(config.Context)
+
+	es := &EventSource{
+		client:       config.Client,
+		retry:        config.Retry,
+		policy:       config.RetryPolicy,
+		classifier:   config.Classify,
+		observer:     config.Observer,
+		clock:        config.Clock,
+		stateEvents:  config.StateEvents,
+		idleTimeout:  config.IdleTimeout,
+		decoder:      config.Decoder,
+		endpoints:    endpoints,
+		failover:     config.Failover,
+		maxRedirects: config.MaxRedirects,
+		moved:        map[string]*url.URL{},
+		ctx:          ctx,
+		cancel:       cancel,
+		store:        config.LastEventIDStore,
+		manual:       config.ManualCommit,
+	}
+
+	if config.Sequencing != nil {
+		es.seq = newSequencer(*config.Sequencing)
+	}
+
+	for _, req := range templates {
+		if req != nil && req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			es.fail(ErrBodyNotRewindable) // fail now, rather than on the first reconnect
+		}
+	}
 
-	return &EventSource{
-		client:  config.Client,
-		retry:   config.Retry,
-		request: config.Request,
+	if es.store != nil {
+		id, err := es.store.Load()
+		if err != nil {
+			es.fail(fmt.Errorf("load last event ID: %w", err))
+		}
+		es.lastEventID = id
 	}
+
+	return es
 }
 
-// Close the source. Any further calls to Read() will return ErrClosed.
+// Close the source. Any further calls to Read() will return ErrClosed. Close
+// may be called from any goroutine, and interrupts a concurrently blocked
+// Read.
 func (es *EventSource) Close() {
-	if es.r != nil {
-		es.r.Close()
+	es.fail(ErrClosed)
+}
+
+// Done returns a channel that's closed when the event source is permanently
+// closed, either by Close, by a fatal error, or because Config.Context is
+// done.
+func (es *EventSource) Done() <-chan struct{} {
+	return es.ctx.Done()
+}
+
+// Err returns nil if Done is not yet closed. Otherwise, it returns the error
+// that closed the event source, which is the same error returned by Read.
+func (es *EventSource) Err() error {
+	if es.ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(es.ctx)
+}
+
+// ReadyState returns the current state of the connection. It may be called
+// from any goroutine.
+func (es *EventSource) ReadyState() ReadyState {
+	if es.ctx.Err() != nil {
+		return StateClosed
+	}
+	return ReadyState(es.state.Load())
+}
+
+// fail permanently closes the event source with err, unless it's already
+// closed, and returns the resulting error.
+func (es *EventSource) fail(err error) error {
+	if es.ctx.Err() == nil && !errors.Is(err, ErrClosed) {
+		es.stats.setErr(err)
+	}
+	es.cancel(err)
+	return es.Err()
+}
+
+// disconnect closes the current connection, if any.
+func (es *EventSource) disconnect() {
+	if es.closeConn != nil {
+		es.closeConn()
+	}
+	es.r, es.dec, es.closeConn, es.idle = nil, nil, nil, nil
+	es.state.Store(int32(StateConnecting))
+}
+
+// wait blocks for the given delay. It returns a non-nil error if ctx is done
+// first, or if the event source is closed.
+func (es *EventSource) wait(ctx context.Context, delay time.Duration) error {
+	t := es.clock.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-es.ctx.Done():
+		return es.Err()
+	}
+}
+
+// failed records a failed connection attempt, or a lost connection, so that
+// the next attempt is delayed according to the retry policy.
+func (es *EventSource) failed(err error, status int, retryAfter time.Duration) {
+	if es.failures == 0 {
+		es.failedAt = es.clock.Now()
+	}
+	es.failures++
+	es.lastErr, es.lastStatus, es.retryAfter = err, status, retryAfter
+	es.stats.setErr(err)
+}
+
+// backoff waits before the next connection attempt, if the previous attempt
+// failed. It returns a non-nil error if ctx is done first, or if the event
+// source is closed, including when the retry policy gives up.
+func (es *EventSource) backoff(ctx context.Context) error {
+	if es.failures == 0 {
+		return nil
+	}
+
+	delay, ok := es.policy.Next(Retry{
+		Attempt:    es.failures,
+		Err:        es.lastErr,
+		StatusCode: es.lastStatus,
+		RetryAfter: es.retryAfter,
+		Hint:       es.retry,
+		Elapsed:    es.clock.Now().Sub(es.failedAt),
+	})
+	if !ok {
+		return es.fail(&RetriesExhaustedError{Attempts: es.failures, Err: es.lastErr})
+	}
+
+	if delay < es.retryAfter {
+		delay = es.retryAfter
+	}
+
+	es.observer.OnRetry(es.failures, delay)
+	es.stats.retryDelay.Store(int64(delay))
+
+	return es.wait(ctx, delay)
+}
+
+// request creates the request for a connection attempt.
+func (es *EventSource) request(ctx context.Context) (*http.Request, error) {
+	lastEventID := es.LastEventID()
+
+	req, err := es.endpoints[es.endpoint.Load()](ctx, lastEventID)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	req = req.WithContext(ctx)
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("Last-Event-Id", lastEventID)
+
+	es.resolveMoved(req)
+
+	return req, nil
+}
+
+// requestTemplate returns a request factory that clones req for every
+// connection attempt, so that the caller's request is never modified. The
+// body of the original request is sent with the first attempt, and rewound
+// via GetBody for subsequent attempts.
+func requestTemplate(req *http.Request) func(context.Context, string) (*http.Request, error) {
+	var sent bool
+	return func(ctx context.Context, _ string) (*http.Request, error) {
+		clone := req.Clone(ctx)
+
+		if req.Body != nil && req.Body != http.NoBody && sent {
+			if req.GetBody == nil {
+				return nil, ErrBodyNotRewindable
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("get body: %w", err)
+			}
+			clone.Body = body
+		}
+
+		sent = true
+		return clone, nil
 	}
-	es.err = ErrClosed
+}
+
+// classify the outcome of a connection attempt with the configured
+// classifier, making sure that the result is coherent.
+func (es *EventSource) classify(resp *http.Response, err error) Classification {
+	c := es.classifier(resp, err)
+
+	if c.Action == ActionAccept && err != nil {
+		c.Action = ActionFatal
+	}
+
+	if c.Action != ActionAccept && c.Err == nil {
+		if err != nil {
+			c.Err = err
+		} else {
+			c.Err = newStatusError(resp)
+		}
+	}
+
+	return c
+}
+
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
 }
 
 // Connect to an event source, validate the response, and gracefully handle
-// reconnects.
-func (es *EventSource) connect() {
-	for es.err == nil {
-		if es.r != nil {
-			es.r.Close()
-			<-time.After(es.retry)
+// reconnects. A non-nil error is returned if ctx is done before a connection
+// is established, or if the event source is closed.
+func (es *EventSource) connect(ctx context.Context) error {
+	es.disconnect()
+
+	for es.ctx.Err() == nil {
+		if err := es.backoff(ctx); err != nil {
+			return err
 		}
 
-		es.request.Header.Set("Last-Event-Id", es.lastEventID)
+		es.switchEndpoint()
 
-		resp, err := es.client.Do(es.request)
-		switch {
-		case errors.Is(err, context.Canceled): // canceled contexts are fatal
-			es.err = err
-			continue
+		connCtx, cancel := context.WithCancel(es.ctx)
 
-		case err != nil: // other execution errors are assumed to be non-fatal
+		req, err := es.request(connCtx)
+		if err != nil {
+			cancel()
+			if c := es.classify(nil, err); c.Action == ActionRetry {
+				es.failed(c.Err, 0, c.RetryAfter)
+			} else {
+				es.fail(c.Err)
+			}
 			continue
+		}
 
-		case resp.StatusCode >= 500: // 5xx are assumed to be temporary
-			resp.Body.Close()
-			continue
+		es.observer.OnConnecting(req)
+		es.stats.attempted.Add(1)
+
+		stop := context.AfterFunc(ctx, cancel)
+		resp, err := es.do(req)
+		if !stop() && es.ctx.Err() == nil { // ctx is done, so abandon this attempt
+			if err == nil {
+				resp.Body.Close()
+			}
+			cancel()
+			es.observer.OnDisconnect(ctx.Err())
+			return ctx.Err()
+		}
 
-		case resp.StatusCode == 204: // 204 No Content is assumed to be fatal
-			resp.Body.Close()
-			es.err = ErrClosed
+		if es.ctx.Err() != nil { // closed during the request
+			if err == nil {
+				resp.Body.Close()
+			}
+			cancel()
+			es.observer.OnDisconnect(es.Err())
 			continue
+		}
 
-		case resp.StatusCode == 200:
-			ct := resp.Header.Get("content-type")
-			mt, _, _ := mime.ParseMediaType(ct)
-			if mt != "text/event-stream" {
+		c := es.classify(resp, err)
+		if c.Action != ActionAccept {
+			if err == nil {
+				readBody(c.Err, resp)
 				resp.Body.Close()
-				es.err = fmt.Errorf("invalid response Content-Type (%s)", ct)
-				continue
 			}
-			es.r = resp.Body
-			es.dec = NewDecoder(es.r)
-			return
+			cancel()
+			es.observer.OnDisconnect(c.Err)
+		}
+
+		es.attempted(c.Action == ActionAccept)
+
+		switch c.Action {
+		case ActionAccept:
+			context.AfterFunc(connCtx, func() { resp.Body.Close() })
+			es.failures, es.lastErr, es.lastStatus, es.retryAfter = 0, nil, 0, 0
+			if es.manual && es.seq != nil {
+				es.seq.rewind(req.Header.Get("Last-Event-Id")) // the server resumes after the last commit
+			}
+			conn := es.stats.succeeded.Add(1)
+			if conn > 1 {
+				es.stats.reconnects.Add(1)
+			}
+			es.meta = Metadata{LastEventID: es.LastEventID(), Connection: conn}
+			if u := es.url.Load(); u != nil {
+				es.meta.URL, es.meta.Origin = u.String(), u.Scheme+"://"+u.Host
+			}
+			es.stats.retryDelay.Store(0)
+			es.r, es.closeConn = countingReader{resp.Body, &es.stats.bytes}, cancel
+			if es.idleTimeout > 0 {
+				idle := newIdleReader(es.r, es.clock, es.idleTimeout, cancel)
+				es.r, es.closeConn, es.idle = idle, func() { idle.stop(); cancel() }, idle
+			}
+			es.dec = NewDecoderConfig(es.r, es.decoder)
+			es.state.Store(int32(StateOpen))
+			es.observer.OnOpen(resp)
+			return nil
+
+		case ActionRetry:
+			es.failed(c.Err, statusCode(resp), c.RetryAfter)
 
 		default:
-			es.err = fmt.Errorf("endpoint returned unrecoverable status %s", resp.Status)
-			continue
+			if es.failOver(c.Err) { // try the other endpoints first
+				es.failed(c.Err, statusCode(resp), c.RetryAfter)
+			} else {
+				es.fail(c.Err)
+			}
+		}
+	}
+
+	return es.Err()
+}
+
+// LastEventID returns the ID sent with the Last-Event-Id header when
+// reconnecting. It may be called from any goroutine.
+func (es *EventSource) LastEventID() string {
+	es.idMtx.Lock()
+	defer es.idMtx.Unlock()
+	return es.lastEventID
+}
+
+// Commit marks the event as processed, advancing the last event ID to the ID
+// of the event, if it has one, and saving it to the LastEventIDStore, if
+// configured. It's only necessary with Config.ManualCommit, and may be called
+// from any goroutine, but events should be committed in the order they were
+// read. If saving fails, the last event ID doesn't advance, and the error is
+// returned.
+func (es *EventSource) Commit(e Event) error {
+	if !es.manual {
+		return nil
+	}
+	return es.commit(e)
+}
+
+func (es *EventSource) commit(e Event) error {
+	if len(e.ID) == 0 && !e.ResetID {
+		return nil
+	}
+
+	es.idMtx.Lock()
+	defer es.idMtx.Unlock()
+
+	if e.ID == es.lastEventID {
+		return nil
+	}
+
+	if es.store != nil {
+		if err := es.store.Save(e.ID); err != nil {
+			return fmt.Errorf("save last event ID: %w", err)
 		}
 	}
+
+	es.lastEventID = e.ID
+	return nil
 }
 
 // Read an event from EventSource. If an error is returned, the EventSource
 // will not reconnect, and any further call to Read() will return the same
-// error.
+// error. The exception is a *GapError, which only reports missing events.
 func (es *EventSource) Read() (Event, error) {
-	if es.r == nil {
-		es.connect()
+	return es.ReadContext(context.Background())
+}
+
+// ReadContext is like Read, but aborts any in-flight request, read, or retry
+// wait when ctx is done, and returns ctx.Err(). That error doesn't close the
+// event source: if a connection was interrupted, the next call reconnects
+// with the last event ID.
+func (es *EventSource) ReadContext(ctx context.Context) (Event, error) {
+	if err := es.Err(); err != nil {
+		es.disconnect()
+		return Event{}, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Event{}, err
+	}
+
+	if es.pending != nil {
+		e := *es.pending
+		es.pending = nil
+		return es.deliver(e)
 	}
 
-	for es.err == nil {
+	for es.ctx.Err() == nil {
+		if es.dec == nil {
+			if err := es.connect(ctx); err != nil {
+				return Event{}, err
+			}
+			if es.stateEvents {
+				return Event{Type: EventTypeOpen}, nil
+			}
+		}
+
 		var e Event
 
+		if es.idle != nil {
+			es.idle.start()
+		}
+
+		stop := context.AfterFunc(ctx, es.closeConn)
 		err := es.dec.Decode(&e)
-		if errors.Is(err, ErrInvalidEncoding) {
+
+		if es.idle != nil {
+			es.idle.stop() // time spent by the caller between reads doesn't count
+		}
+		if !stop() && es.ctx.Err() == nil { // ctx is done, and the connection was closed
+			es.disconnect()
+			es.observer.OnDisconnect(ctx.Err())
+			return Event{}, ctx.Err()
+		}
+
+		if errors.Is(err, ErrInvalidEncoding) || errors.Is(err, ErrTooLarge) {
+			es.observer.OnInvalidEvent(err)
+			es.stats.invalid.Add(1)
 			continue
 		}
 		if err != nil {
-			es.connect()
+			if cause := es.Err(); cause != nil {
+				err = cause
+			}
+			es.observer.OnDisconnect(err)
+			es.failed(err, 0, 0)
+			es.lost()
+			es.disconnect()
+			if es.stateEvents && es.ctx.Err() == nil {
+				return Event{Type: EventTypeError}, nil
+			}
 			continue
 		}
 
+		e.Metadata = es.meta
+		e.Metadata.ReceivedAt = es.clock.Now()
+
 		if len(e.Data) == 0 {
 			continue
 		}
 
-		if len(e.ID) > 0 || e.ResetID {
-			es.lastEventID = e.ID
-		}
-
 		if len(e.Retry) > 0 {
 			if retry, err := strconv.Atoi(e.Retry); err == nil {
 				es.retry = time.Duration(retry) * time.Millisecond
 			}
 		}
 
-		return e, nil
+		if es.seq != nil {
+			duplicate, gap := es.seq.check(e)
+			if duplicate {
+				continue
+			}
+			if gap != nil {
+				es.pending = &e
+				return Event{}, gap
+			}
+		}
+
+		return es.deliver(e)
 	}
 
-	return Event{}, es.err
+	es.disconnect()
+	return Event{}, es.Err()
+}
+
+// deliver commits the event, unless commits are manual, and returns it.
+func (es *EventSource) deliver(e Event) (Event, error) {
+	if !es.manual {
+		if err := es.commit(e); err != nil {
+			es.disconnect()
+			return Event{}, es.fail(err)
+		}
+	}
+
+	if len(e.ID) > 0 || e.ResetID {
+		es.meta.LastEventID = e.ID
+	}
+	e.Metadata.LastEventID = es.meta.LastEventID
+
+	es.stats.delivered.Add(1)
+	es.stats.lastEventAt.Store(es.clock.Now().UnixNano())
+
+	return e, nil
 }
@@ -2,11 +2,16 @@ package eventsource
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -25,9 +30,9 @@ func TestEventSource200NoContentType(t *testing.T) {
 	retry := time.Millisecond
 	es := New(req, retry)
 
-	es.connect()
+	es.connect(context.Background())
 
-	if es.err == nil {
+	if es.Err() == nil {
 		t.Fatalf("200 OK with no Content-Type should have errored, but didn't")
 	}
 }
@@ -48,7 +53,7 @@ func TestEventSourceHeaders(t *testing.T) {
 	retry := time.Duration(-1)
 	es := New(req, retry)
 
-	es.connect()
+	es.connect(context.Background())
 
 	h := <-headers
 
@@ -74,8 +79,8 @@ func TestEventSource204(t *testing.T) {
 	retry := time.Millisecond
 	es := New(req, retry)
 
-	es.connect()
-	if es.err == nil {
+	es.connect(context.Background())
+	if es.Err() == nil {
 		t.Fatalf("204 No Content should have errored, but didn't")
 	}
 }
@@ -102,9 +107,9 @@ func TestEventSourceEmphemeral500(t *testing.T) {
 	retry := time.Millisecond
 	es := New(req, retry)
 
-	es.connect()
-	if es.err != nil {
-		t.Fatalf("500 Internal Server Error should have reconnected, but didn't; error: %v", es.err)
+	es.connect(context.Background())
+	if es.Err() != nil {
+		t.Fatalf("500 Internal Server Error should have reconnected, but didn't; error: %v", es.Err())
 	}
 }
 
@@ -258,6 +263,7 @@ func TestEventSourceBOM(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	event.Metadata = Metadata{} // differs between otherwise equal events
 	if !reflect.DeepEqual(event, Event{Type: "custom", Data: []byte("foo")}) {
 		t.Fatal("message was unsuccessfully decoded with BOM")
 	}
@@ -278,3 +284,499 @@ func request(url string) *http.Request {
 	req, _ := http.NewRequest("GET", url, nil)
 	return req
 }
+
+func TestEventSourceReadContext(t *testing.T) {
+	t.Parallel()
+
+	lastIDs := make(chan string, 2)
+	server := testServer(func(w responseWriter, r *http.Request) {
+		lastID := r.Header.Get("Last-Event-Id")
+		lastIDs <- lastID
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+
+		id, _ := strconv.Atoi(lastID)
+		fmt.Fprintf(w, "id: %d\ndata: message %d\n\n", id+1, id+1)
+		w.Flush()
+
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	defer es.Close()
+
+	if _, err := es.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := es.ReadContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+
+	event, err := es.Read()
+	if err != nil {
+		t.Fatalf("event source should still be usable, but Read failed: %v", err)
+	}
+	if want, have := "2", event.ID; want != have {
+		t.Errorf("ID: want %q, have %q", want, have)
+	}
+
+	if want, have := "", <-lastIDs; want != have {
+		t.Errorf("first Last-Event-Id: want %q, have %q", want, have)
+	}
+	if want, have := "1", <-lastIDs; want != have {
+		t.Errorf("second Last-Event-Id: want %q, have %q", want, have)
+	}
+}
+
+func TestEventSourceReadContextRetryWait(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(503)
+		}),
+	)
+	defer server.Close()
+
+	es := New(request(server.URL), time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := es.ReadContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+	if es.Err() != nil {
+		t.Fatalf("event source should not be closed, but has error %v", es.Err())
+	}
+}
+
+func TestEventSourceConfigContext(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		w.Flush()
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	es := NewConfig(Config{
+		Request: request(server.URL),
+		Context: ctx,
+	})
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if _, err := es.Read(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want %v, have %v", context.Canceled, err)
+	}
+	if _, err := es.Read(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("subsequent Read: want %v, have %v", context.Canceled, err)
+	}
+}
+
+func TestEventSourceCloseConcurrent(t *testing.T) {
+	t.Parallel()
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"read": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(200)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		},
+		"retry": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(503)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			es := New(request(server.URL), time.Hour)
+
+			select {
+			case <-es.Done():
+				t.Fatal("Done closed before Close")
+			default:
+			}
+			if err := es.Err(); err != nil {
+				t.Fatalf("Err before Close: want nil, have %v", err)
+			}
+
+			errc := make(chan error, 1)
+			go func() {
+				_, err := es.Read()
+				errc <- err
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+			es.Close()
+
+			select {
+			case err := <-errc:
+				if !errors.Is(err, ErrClosed) {
+					t.Errorf("Read: want %v, have %v", ErrClosed, err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Read wasn't interrupted by Close")
+			}
+
+			select {
+			case <-es.Done():
+			default:
+				t.Fatal("Done not closed after Close")
+			}
+			if want, have := ErrClosed, es.Err(); !errors.Is(have, want) {
+				t.Errorf("Err: want %v, have %v", want, have)
+			}
+		})
+	}
+}
+
+func TestEventSourceNewRequest(t *testing.T) {
+	t.Parallel()
+
+	type attempt struct{ auth, accept, lastID string }
+	attempts := make(chan attempt, 2)
+	server := testServer(func(w responseWriter, r *http.Request) {
+		attempts <- attempt{r.Header.Get("Authorization"), r.Header.Get("Accept"), r.Header.Get("Last-Event-Id")}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+
+		id, _ := strconv.Atoi(r.Header.Get("Last-Event-Id"))
+		NewEncoder(w).Encode(Event{ID: strconv.Itoa(id + 1), Data: []byte("foo")})
+	})
+	defer server.Close()
+
+	var (
+		tokens  int
+		lastIDs []string
+	)
+	es := NewConfig(Config{
+		Retry: time.Millisecond,
+		NewRequest: func(ctx context.Context, lastEventID string) (*http.Request, error) {
+			tokens++
+			lastIDs = append(lastIDs, lastEventID)
+			req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer token-%d", tokens))
+			return req, nil
+		},
+	})
+	defer es.Close()
+
+	for _, want := range []attempt{
+		{"Bearer token-1", "text/event-stream", ""},
+		{"Bearer token-2", "text/event-stream", "1"},
+	} {
+		if _, err := es.Read(); err != nil {
+			t.Fatal(err)
+		}
+		if have := <-attempts; want != have {
+			t.Errorf("want %+v, have %+v", want, have)
+		}
+	}
+
+	if want, have := []string{"", "1"}, lastIDs; !reflect.DeepEqual(want, have) {
+		t.Errorf("lastEventID: want %q, have %q", want, have)
+	}
+}
+
+func TestEventSourceNewRequestError(t *testing.T) {
+	t.Parallel()
+
+	errToken := errors.New("token unavailable")
+
+	t.Run("retry", func(t *testing.T) {
+		t.Parallel()
+
+		server := testServer(func(w responseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(200)
+			NewEncoder(w).Encode(Event{Data: []byte("foo")})
+		})
+		defer server.Close()
+
+		var calls int
+		es := NewConfig(Config{
+			Retry: time.Millisecond,
+			NewRequest: func(ctx context.Context, _ string) (*http.Request, error) {
+				if calls++; calls < 3 {
+					return nil, errToken
+				}
+				return http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+			},
+		})
+		defer es.Close()
+
+		if _, err := es.Read(); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := 3, calls; want != have {
+			t.Errorf("calls: want %d, have %d", want, have)
+		}
+	})
+
+	t.Run("fatal", func(t *testing.T) {
+		t.Parallel()
+
+		es := NewConfig(Config{
+			Retry: time.Millisecond,
+			NewRequest: func(context.Context, string) (*http.Request, error) {
+				return nil, ErrBodyNotRewindable
+			},
+		})
+
+		if _, err := es.Read(); !errors.Is(err, ErrBodyNotRewindable) {
+			t.Fatalf("want %v, have %v", ErrBodyNotRewindable, err)
+		}
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		t.Parallel()
+
+		es := NewConfig(Config{
+			Retry:       time.Millisecond,
+			RetryPolicy: ConstantBackoff{MaxAttempts: 2},
+			NewRequest: func(context.Context, string) (*http.Request, error) {
+				return nil, errToken
+			},
+		})
+
+		if _, err := es.Read(); !errors.Is(err, errToken) {
+			t.Fatalf("want %v, have %v", errToken, err)
+		}
+	})
+}
+
+func TestEventSourceRequestBody(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan string, 2)
+	server := testServer(func(w responseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- r.Method + " " + string(body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	})
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader(`{"query":"foo"}`))
+	es := New(req, time.Millisecond)
+	defer es.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := es.Read(); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := `POST {"query":"foo"}`, <-bodies; want != have {
+			t.Errorf("%d: want %q, have %q", i, want, have)
+		}
+	}
+
+	if len(req.Header) != 0 {
+		t.Errorf("request template headers were modified: %v", req.Header)
+	}
+}
+
+func TestEventSourceRequestBodyNotRewindable(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	server := testServer(func(w responseWriter, r *http.Request) {
+		hits.Add(1)
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	})
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", server.URL, struct{ io.Reader }{strings.NewReader("foo")})
+	es := New(req, time.Millisecond)
+	defer es.Close()
+
+	if err := es.Err(); !errors.Is(err, ErrBodyNotRewindable) {
+		t.Fatalf("Err: want %v, have %v", ErrBodyNotRewindable, err)
+	}
+	if _, err := es.Read(); !errors.Is(err, ErrBodyNotRewindable) {
+		t.Fatalf("Read: want %v, have %v", ErrBodyNotRewindable, err)
+	}
+	if want, have := int32(0), hits.Load(); want != have {
+		t.Errorf("requests: want %d, have %d", want, have)
+	}
+}
+
+func TestEventSourceStateEvents(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	})
+	defer server.Close()
+
+	es := NewConfig(Config{
+		Request:     request(server.URL),
+		Retry:       time.Millisecond,
+		StateEvents: true,
+	})
+
+	if want, have := StateConnecting, es.ReadyState(); want != have {
+		t.Errorf("initial ReadyState: want %s, have %s", want, have)
+	}
+
+	for i, want := range []struct {
+		typ   string
+		data  string
+		state ReadyState
+	}{
+		{EventTypeOpen, "", StateOpen},
+		{"message", "foo", StateOpen},
+		{EventTypeError, "", StateConnecting},
+		{EventTypeOpen, "", StateOpen},
+		{"message", "foo", StateOpen},
+	} {
+		event, err := es.Read()
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if want, have := want.typ, event.Type; want != have {
+			t.Errorf("%d: Type: want %q, have %q", i, want, have)
+		}
+		if want, have := want.data, string(event.Data); want != have {
+			t.Errorf("%d: Data: want %q, have %q", i, want, have)
+		}
+		if want, have := want.state, es.ReadyState(); want != have {
+			t.Errorf("%d: ReadyState: want %s, have %s", i, want, have)
+		}
+	}
+
+	es.Close()
+
+	if want, have := StateClosed, es.ReadyState(); want != have {
+		t.Errorf("ReadyState after Close: want %s, have %s", want, have)
+	}
+}
+
+func TestEventSourceMetadata(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	lastIDs := make(chan string, 2)
+	server := testServer(func(w responseWriter, r *http.Request) {
+		requests++
+		lastIDs <- r.Header.Get("Last-Event-Id")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		enc := NewEncoder(w)
+		if requests == 1 {
+			enc.Encode(Event{ID: "1", Data: []byte("foo")})
+			fmt.Fprint(w, "id: 7\n\n") // not returned by Read, so not resumed from
+			enc.Encode(Event{Data: []byte("bar")})
+			return
+		}
+		enc.Encode(Event{ID: "2", Data: []byte("baz")})
+		w.Flush()
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	es := New(request(server.URL+"/events"), time.Millisecond)
+	defer es.Close()
+
+	begin := time.Now()
+	for _, want := range []struct {
+		lastEventID string
+		connection  int64
+	}{
+		{"1", 1},
+		{"1", 1},
+		{"2", 2},
+	} {
+		event, err := es.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta := event.Metadata
+		if want, have := want.lastEventID, meta.LastEventID; want != have {
+			t.Errorf("%s: LastEventID: want %q, have %q", event.Data, want, have)
+		}
+		if want, have := want.connection, meta.Connection; want != have {
+			t.Errorf("%s: Connection: want %d, have %d", event.Data, want, have)
+		}
+		if want, have := server.URL+"/events", meta.URL; want != have {
+			t.Errorf("%s: URL: want %q, have %q", event.Data, want, have)
+		}
+		if want, have := server.URL, meta.Origin; want != have {
+			t.Errorf("%s: Origin: want %q, have %q", event.Data, want, have)
+		}
+		if meta.ReceivedAt.Before(begin) {
+			t.Errorf("%s: ReceivedAt: want after %v, have %v", event.Data, begin, meta.ReceivedAt)
+		}
+	}
+
+	if want, have := "", <-lastIDs; want != have {
+		t.Errorf("first Last-Event-Id: want %q, have %q", want, have)
+	}
+	if want, have := "1", <-lastIDs; want != have {
+		t.Errorf("second Last-Event-Id: want %q, have %q", want, have)
+	}
+}
+
+func TestEventSourceDecoderLimits(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		enc := NewEncoder(w)
+		enc.Encode(Event{Data: bytes.Repeat([]byte("x"), 1024)})
+		enc.Encode(Event{Data: []byte("foo")})
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	es := NewConfig(Config{
+		Request:  request(server.URL),
+		Decoder:  DecoderConfig{MaxDataSize: 512},
+		Observer: observer,
+	})
+	defer es.Close()
+
+	event, err := es.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "foo", string(event.Data); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	want := []string{
+		"connecting",
+		"open 200",
+		"invalid too large: line longer than 518 bytes",
+	}
+	if have := observer.calls; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := int64(1), es.Stats().InvalidEvents; want != have {
+		t.Errorf("InvalidEvents: want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,153 @@
+// Package eventsourcetest provides utilities for testing code that uses
+// package eventsource.
+package eventsourcetest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/peterbourgon/eventsource"
+)
+
+// Clock is a fake eventsource.Clock, whose time only moves when Advance is
+// called. It's safe for concurrent use.
+type Clock struct {
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers map[*timer]struct{}
+}
+
+var _ eventsource.Clock = (*Clock)(nil)
+
+// NewClock returns a fake clock set to the given time.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{
+		now:    now,
+		timers: map[*timer]struct{}{},
+	}
+	c.cond = sync.NewCond(&c.mtx)
+	return c
+}
+
+// Now implements eventsource.Clock.
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// NewTimer implements eventsource.Clock.
+func (c *Clock) NewTimer(d time.Duration) eventsource.Timer {
+	return c.newTimer(d, make(chan time.Time, 1), nil)
+}
+
+// AfterFunc implements eventsource.Clock.
+func (c *Clock) AfterFunc(d time.Duration, f func()) eventsource.Timer {
+	return c.newTimer(d, nil, f)
+}
+
+// Advance moves the clock forward by d, firing every timer that expires in
+// the meantime, in order. Like time.AfterFunc, functions of AfterFunc timers
+// are called in their own goroutines.
+func (c *Clock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	var expired []*timer
+	for t := range c.timers {
+		if !t.when.After(now) {
+			expired = append(expired, t)
+			delete(c.timers, t)
+		}
+	}
+	c.cond.Broadcast()
+	c.mtx.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].when.Before(expired[j].when)
+	})
+
+	for _, t := range expired {
+		t.fire(now)
+	}
+}
+
+// Timers returns the number of active timers.
+func (c *Clock) Timers() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil blocks until there are at least n active timers. Use it to wait
+// for code under test to start waiting, before calling Advance.
+func (c *Clock) BlockUntil(n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *Clock) newTimer(d time.Duration, ch chan time.Time, f func()) *timer {
+	t := &timer{clock: c, ch: ch, f: f}
+	t.Reset(d)
+	return t
+}
+
+type timer struct {
+	clock *Clock
+	when  time.Time
+	ch    chan time.Time
+	f     func()
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	_, active := c.timers[t]
+	delete(c.timers, t)
+	c.cond.Broadcast()
+	return active
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mtx.Lock()
+	_, active := c.timers[t]
+	t.when = c.now.Add(d)
+	if d > 0 {
+		c.timers[t] = struct{}{}
+	} else {
+		delete(c.timers, t)
+	}
+	c.cond.Broadcast()
+	now := c.now
+	c.mtx.Unlock()
+
+	if d <= 0 {
+		t.fire(now)
+	}
+
+	return active
+}
+
+func (t *timer) fire(now time.Time) {
+	if t.f != nil {
+		go t.f()
+		return
+	}
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
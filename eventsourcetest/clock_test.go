@@ -0,0 +1,67 @@
+package eventsourcetest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(begin)
+
+	short, long := clock.NewTimer(time.Second), clock.NewTimer(time.Minute)
+	called := make(chan time.Time, 1)
+	clock.AfterFunc(time.Second, func() { called <- clock.Now() })
+
+	if want, have := 3, clock.Timers(); want != have {
+		t.Fatalf("Timers: want %d, have %d", want, have)
+	}
+
+	clock.Advance(time.Second)
+
+	if want, have := begin.Add(time.Second), <-short.C(); !want.Equal(have) {
+		t.Errorf("short timer: want %v, have %v", want, have)
+	}
+	if want, have := begin.Add(time.Second), <-called; !want.Equal(have) {
+		t.Errorf("AfterFunc: want %v, have %v", want, have)
+	}
+	select {
+	case <-long.C():
+		t.Errorf("long timer fired early")
+	default:
+	}
+
+	if !long.Stop() {
+		t.Errorf("Stop: want true for active timer")
+	}
+	if short.Stop() {
+		t.Errorf("Stop: want false for fired timer")
+	}
+	if want, have := 0, clock.Timers(); want != have {
+		t.Fatalf("Timers: want %d, have %d", want, have)
+	}
+
+	long.Reset(time.Hour)
+	clock.Advance(time.Hour)
+	if want, have := begin.Add(time.Hour+time.Second), <-long.C(); !want.Equal(have) {
+		t.Errorf("reset timer: want %v, have %v", want, have)
+	}
+}
+
+func TestClockBlockUntil(t *testing.T) {
+	t.Parallel()
+
+	clock := NewClock(time.Now())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-clock.NewTimer(time.Minute).C()
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	<-done
+}
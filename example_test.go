@@ -1,6 +1,7 @@
 package eventsource_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -121,6 +122,31 @@ data: 123
 	// 3. add 123
 }
 
+func ExampleDecoder_All() {
+	stream := strings.NewReader(`id: 1
+event: add
+data: 123
+
+id: 2
+event: remove
+data: 321
+
+`)
+	dec := eventsource.NewDecoder(stream)
+
+	for event, err := range dec.All() {
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%s. %s %s\n", event.ID, event.Type, event.Data)
+	}
+
+	// Output:
+	// 1. add 123
+	// 2. remove 321
+}
+
 func ExampleNew() {
 	req, _ := http.NewRequest("GET", "http://localhost:9090/events", nil)
 	req.SetBasicAuth("user", "pass")
@@ -137,3 +163,51 @@ func ExampleNew() {
 		log.Printf("%s. %s %s\n", event.ID, event.Type, event.Data)
 	}
 }
+
+func ExampleMux() {
+	stream := strings.NewReader(`event: add
+data: 123
+
+event: remove
+data: 321
+
+event: user.login
+data: alice
+
+event: unknown
+data: ???
+
+`)
+
+	var mux eventsource.Mux
+
+	mux.HandleFunc("add", func(_ context.Context, e eventsource.Event) error {
+		fmt.Printf("added %s\n", e.Data)
+		return nil
+	})
+
+	mux.HandleFunc("remove", func(_ context.Context, e eventsource.Event) error {
+		fmt.Printf("removed %s\n", e.Data)
+		return nil
+	})
+
+	mux.HandleFunc("user.*", func(_ context.Context, e eventsource.Event) error {
+		fmt.Printf("%s %s\n", e.Type, e.Data)
+		return nil
+	})
+
+	mux.HandleFunc("*", func(_ context.Context, e eventsource.Event) error {
+		fmt.Printf("unhandled %s\n", e.Type)
+		return nil
+	})
+
+	if err := mux.Run(context.Background(), eventsource.NewDecoder(stream)); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// added 123
+	// removed 321
+	// user.login alice
+	// unhandled unknown
+}
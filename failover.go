@@ -0,0 +1,100 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+)
+
+// Failover is the strategy an EventSource uses to choose between multiple
+// endpoints.
+type Failover int
+
+const (
+	// FailoverOrdered prefers endpoints in the order they're listed. After a
+	// failed connection attempt, the next endpoint is tried; whenever an open
+	// connection is lost, the first endpoint is tried again. It's the default.
+	FailoverOrdered Failover = iota
+
+	// FailoverRoundRobin uses the next endpoint for every connection attempt,
+	// whether or not the previous attempt succeeded.
+	FailoverRoundRobin
+
+	// FailoverSticky keeps using the same endpoint, including to reconnect
+	// after a lost connection, until a connection attempt fails, and then
+	// moves on to the next endpoint.
+	FailoverSticky
+)
+
+// FailoverObserver may be implemented by an Observer to be notified when an
+// EventSource with multiple endpoints switches between them. Endpoints are
+// identified by their index in Config.Endpoints.
+type FailoverObserver interface {
+	OnFailover(from, to int)
+}
+
+// Endpoint returns the index in Config.Endpoints of the endpoint used for the
+// current or most recent connection attempt. It's always zero if multiple
+// endpoints aren't configured.
+func (es *EventSource) Endpoint() int {
+	return int(es.endpoint.Load())
+}
+
+// attempted chooses the endpoint for the next connection attempt, after an
+// attempt either succeeded or failed.
+func (es *EventSource) attempted(succeeded bool) {
+	cur := int(es.endpoint.Load())
+	if succeeded {
+		es.fatal = nil
+	}
+
+	switch {
+	case !succeeded, es.failover == FailoverRoundRobin:
+		es.nextEndpoint = (cur + 1) % len(es.endpoints)
+	default:
+		es.nextEndpoint = cur
+	}
+}
+
+// failOver records a fatal failure of the current endpoint, and reports
+// whether there are other endpoints left to try, rather than closing the
+// event source.
+func (es *EventSource) failOver(err error) bool {
+	if len(es.endpoints) < 2 || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if es.fatal == nil {
+		es.fatal = make([]bool, len(es.endpoints))
+	}
+	es.fatal[es.endpoint.Load()] = true
+
+	for _, fatal := range es.fatal {
+		if !fatal {
+			return true
+		}
+	}
+	return false
+}
+
+// lost chooses the endpoint to reconnect to, after an open connection was
+// lost.
+func (es *EventSource) lost() {
+	if es.failover == FailoverOrdered {
+		es.nextEndpoint = 0
+	}
+}
+
+// switchEndpoint switches to the chosen endpoint, if it's different from the
+// current one, and notifies the observer.
+func (es *EventSource) switchEndpoint() {
+	from, to := int(es.endpoint.Load()), es.nextEndpoint
+	if from == to {
+		return
+	}
+
+	es.endpoint.Store(int32(to))
+
+	if o, ok := es.observer.(FailoverObserver); ok {
+		o.OnFailover(from, to)
+	}
+}
@@ -0,0 +1,181 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type failoverObserver struct {
+	NopObserver
+	switches []string
+}
+
+func (o *failoverObserver) OnFailover(from, to int) {
+	o.switches = append(o.switches, fmt.Sprintf("%d->%d", from, to))
+}
+
+func TestEventSourceFailover(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		failover Failover
+		down     []bool
+		hits     []string
+		switches []string
+	}{
+		{
+			name:     "ordered",
+			failover: FailoverOrdered,
+			down:     []bool{true, false, false},
+			hits:     []string{"0", "1", "0", "1", "0", "1"},
+			switches: []string{"0->1", "1->0", "0->1", "1->0", "0->1"},
+		},
+		{
+			name:     "sticky",
+			failover: FailoverSticky,
+			down:     []bool{true, false, false},
+			hits:     []string{"0", "1", "1", "1"},
+			switches: []string{"0->1"},
+		},
+		{
+			name:     "round robin",
+			failover: FailoverRoundRobin,
+			down:     []bool{false, true, false},
+			hits:     []string{"0", "1", "2", "0"},
+			switches: []string{"0->1", "1->2", "2->0"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mtx  sync.Mutex
+				hits []string
+			)
+
+			var endpoints []*http.Request
+			for i, down := range tt.down {
+				name := fmt.Sprint(i)
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					mtx.Lock()
+					hits = append(hits, name)
+					mtx.Unlock()
+
+					if down {
+						w.WriteHeader(503)
+						return
+					}
+
+					w.Header().Set("Content-Type", "text/event-stream")
+					w.WriteHeader(200)
+					fmt.Fprintf(w, "id: %s\ndata: last=%s\n\n", name, r.Header.Get("Last-Event-Id"))
+				}))
+				defer server.Close()
+
+				endpoints = append(endpoints, request(server.URL))
+			}
+
+			observer := &failoverObserver{}
+			es := NewConfig(Config{
+				Endpoints: endpoints,
+				Failover:  tt.failover,
+				Retry:     time.Millisecond,
+				Observer:  observer,
+			})
+			defer es.Close()
+
+			var lastID string
+			for i := 0; i < 3; i++ {
+				event, err := es.Read()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want, have := "last="+lastID, string(event.Data); want != have {
+					t.Errorf("%d: Last-Event-Id: want %q, have %q", i, want, have)
+				}
+				if want, have := event.ID, fmt.Sprint(es.Endpoint()); want != have {
+					t.Errorf("%d: Endpoint: want %s, have %s", i, want, have)
+				}
+				lastID = event.ID
+			}
+
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			if want, have := tt.hits, hits; !reflect.DeepEqual(want, have) {
+				t.Errorf("hits: want %q, have %q", want, have)
+			}
+			if want, have := tt.switches, observer.switches; !reflect.DeepEqual(want, have) {
+				t.Errorf("switches: want %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestEventSourceFailoverFatal(t *testing.T) {
+	t.Parallel()
+
+	notFound := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(404)
+	}
+	html := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(200)
+	}
+	events := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	}
+
+	for _, tt := range []struct {
+		name     string
+		handlers []http.HandlerFunc
+		ok       bool
+	}{
+		{"one healthy", []http.HandlerFunc{notFound, html, events}, true},
+		{"none healthy", []http.HandlerFunc{notFound, html}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var endpoints []*http.Request
+			for _, h := range tt.handlers {
+				server := httptest.NewServer(h)
+				defer server.Close()
+				endpoints = append(endpoints, request(server.URL))
+			}
+
+			es := NewConfig(Config{
+				Endpoints: endpoints,
+				Retry:     time.Millisecond,
+			})
+			defer es.Close()
+
+			event, err := es.Read()
+			if !tt.ok {
+				var ce *ContentTypeError
+				if !errors.As(err, &ce) {
+					t.Fatalf("want *ContentTypeError, have %T: %v", err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, have := "foo", string(event.Data); want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+			if want, have := 2, es.Endpoint(); want != have {
+				t.Errorf("Endpoint: want %d, have %d", want, have)
+			}
+		})
+	}
+}
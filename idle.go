@@ -0,0 +1,47 @@
+package eventsource
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// idleReader wraps a connection body, and closes the connection if no bytes
+// are read within the timeout while it's started. Once that happens, reads
+// fail with ErrIdleTimeout.
+type idleReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   Timer
+	expired atomic.Bool
+}
+
+func newIdleReader(r io.Reader, clock Clock, timeout time.Duration, closeConn func()) *idleReader {
+	ir := &idleReader{r: r, timeout: timeout}
+	ir.timer = clock.AfterFunc(timeout, func() {
+		ir.expired.Store(true)
+		closeConn()
+	})
+	ir.timer.Stop()
+	return ir
+}
+
+// start the timeout, when reading resumes.
+func (r *idleReader) start() {
+	r.timer.Reset(r.timeout)
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if r.expired.Load() {
+		return n, ErrIdleTimeout
+	}
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+func (r *idleReader) stop() {
+	r.timer.Stop()
+}
@@ -0,0 +1,159 @@
+package eventsource_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/peterbourgon/eventsource"
+	"github.com/peterbourgon/eventsource/eventsourcetest"
+)
+
+// pipeConn is a connection opened by a pipeClient. The test writes the event
+// stream to w.
+type pipeConn struct {
+	lastID string
+	w      *io.PipeWriter
+}
+
+// pipeClient responds to every request with an event stream whose body is
+// written by the test, so that reads can be synchronized with a fake clock.
+type pipeClient chan pipeConn
+
+func (c pipeClient) Do(req *http.Request) (*http.Response, error) {
+	pr, pw := io.Pipe()
+	c <- pipeConn{req.Header.Get("Last-Event-Id"), pw}
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       pr,
+		Request:    req,
+	}, nil
+}
+
+// send writes s to the connection, and returns once it has been read.
+func (c pipeConn) send(s string) {
+	io.WriteString(c.w, s)
+}
+
+// heartbeat sends a comment, and waits until the event source is waiting for
+// more data, which means it has handled the comment.
+func (c pipeConn) heartbeat() {
+	c.send(": heartbeat\n")
+	c.w.Write(nil) // only returns once the next read has started
+}
+
+type idleObserver struct {
+	eventsource.NopObserver
+	timeouts int
+}
+
+func (o *idleObserver) OnDisconnect(err error) {
+	if errors.Is(err, eventsource.ErrIdleTimeout) {
+		o.timeouts++
+	}
+}
+
+func TestEventSourceIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	conns := make(pipeClient, 2)
+	clock := eventsourcetest.NewClock(time.Now())
+	observer := &idleObserver{}
+	req, _ := http.NewRequest("GET", "http://example.com/events", nil)
+	es := eventsource.NewConfig(eventsource.Config{
+		Client:      conns,
+		Request:     req,
+		Retry:       time.Minute,
+		IdleTimeout: time.Hour,
+		Observer:    observer,
+		Clock:       clock,
+	})
+	defer es.Close()
+
+	result := read(es)
+	conn := <-conns
+	conn.send("id: 1\ndata: foo\n\n")
+	if r := <-result; r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	result = read(es)
+	clock.BlockUntil(1)
+
+	for i := 0; i < 3; i++ { // heartbeats keep the connection open
+		clock.Advance(time.Hour - time.Minute)
+		conn.heartbeat()
+	}
+
+	clock.Advance(time.Hour - time.Minute)
+	select {
+	case r := <-result:
+		t.Fatalf("Read returned before the idle timeout: %v", r.err)
+	case conn := <-conns:
+		t.Fatalf("reconnected before the idle timeout, with Last-Event-Id %q", conn.lastID)
+	default:
+	}
+	clock.Advance(time.Minute)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	conn = <-conns
+	conn.send("id: 2\ndata: foo\n\n")
+	r := <-result
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if want, have := "2", r.event.ID; want != have {
+		t.Errorf("ID: want %q, have %q", want, have)
+	}
+	if want, have := "1", conn.lastID; want != have {
+		t.Errorf("Last-Event-Id: want %q, have %q", want, have)
+	}
+	if want, have := 1, observer.timeouts; want != have {
+		t.Errorf("idle timeouts: want %d, have %d", want, have)
+	}
+}
+
+func TestEventSourceIdleTimeoutSlowConsumer(t *testing.T) {
+	t.Parallel()
+
+	conns := make(pipeClient, 2)
+	clock := eventsourcetest.NewClock(time.Now())
+	req, _ := http.NewRequest("GET", "http://example.com/events", nil)
+	es := eventsource.NewConfig(eventsource.Config{
+		Client:      conns,
+		Request:     req,
+		Retry:       time.Minute,
+		IdleTimeout: time.Hour,
+		Clock:       clock,
+	})
+	defer es.Close()
+
+	result := read(es)
+	conn := <-conns
+
+	for i := 0; i < 3; i++ {
+		conn.send("data: foo\n\n")
+		if r := <-result; r.err != nil {
+			t.Fatal(r.err)
+		}
+
+		if want, have := 0, clock.Timers(); want != have {
+			t.Fatalf("timers between reads: want %d, have %d", want, have)
+		}
+		clock.Advance(2 * time.Hour) // the caller is slow to read again
+
+		result = read(es)
+		clock.BlockUntil(1)
+	}
+
+	select {
+	case conn := <-conns:
+		t.Errorf("reconnected, with Last-Event-Id %q", conn.lastID)
+	default:
+	}
+}
@@ -0,0 +1,64 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+)
+
+// All returns an iterator over the events decoded from the input, which ends
+// at the end of the input. It's AllContext with context.Background.
+func (d *Decoder) All() iter.Seq2[Event, error] {
+	return d.AllContext(context.Background())
+}
+
+// AllContext returns an iterator over the events decoded from the input, which
+// ends at the end of the input. Any other error is yielded with an empty
+// event. The iterator continues after ErrInvalidEncoding and ErrTooLarge, and
+// ends after any other error, or when ctx is done.
+func (d *Decoder) AllContext(ctx context.Context) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for {
+			e, err := d.ReadContext(ctx)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(e, err) {
+				return
+			}
+			if err != nil && !errors.Is(err, ErrInvalidEncoding) && !errors.Is(err, ErrTooLarge) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the events read from the event source. It's
+// AllContext with context.Background.
+func (es *EventSource) All() iter.Seq2[Event, error] {
+	return es.AllContext(context.Background())
+}
+
+// AllContext returns an iterator over the events read from the event source
+// with ReadContext. Any error is yielded with an empty event. The iterator
+// continues after a *GapError, and ends after any other error, which means
+// either ctx is done or the event source is closed. Breaking out of the loop
+// closes the current connection, but not the event source: iterating again, or
+// calling Read, reconnects with the last event ID. Like Read, the iterator
+// should only be used from a single goroutine.
+func (es *EventSource) AllContext(ctx context.Context) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for {
+			e, err := es.ReadContext(ctx)
+			if !yield(e, err) {
+				es.disconnect()
+				return
+			}
+			var gap *GapError
+			if err != nil && !errors.As(err, &gap) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecoderAll(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(strings.NewReader("data: foo\n\ndata: \xFF\ndata: bar\n\ndata: baz\n\n"))
+
+	var (
+		data []string
+		errs int
+	)
+	for e, err := range dec.All() {
+		if errors.Is(err, ErrInvalidEncoding) {
+			errs++
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, string(e.Data))
+		if len(data) == 2 {
+			break
+		}
+	}
+
+	if want, have := []string{"foo", "bar"}, data; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1, errs; want != have {
+		t.Errorf("errors: want %d, have %d", want, have)
+	}
+
+	for e, err := range dec.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "baz", string(e.Data); want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+}
+
+func TestEventSourceAll(t *testing.T) {
+	t.Parallel()
+
+	lastIDs := make(chan string, 2)
+	closed := make(chan struct{}, 2)
+	server := testServer(func(w responseWriter, r *http.Request) {
+		lastID := r.Header.Get("Last-Event-Id")
+		lastIDs <- lastID
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+
+		id, _ := strconv.Atoi(lastID)
+		enc := NewEncoder(w)
+		enc.Encode(Event{ID: strconv.Itoa(id + 1), Data: []byte("foo")})
+		enc.Encode(Event{ID: strconv.Itoa(id + 2), Data: []byte("foo")})
+		<-r.Context().Done()
+		closed <- struct{}{}
+	})
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	defer es.Close()
+
+	for e, err := range es.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "1", e.ID; want != have {
+			t.Errorf("ID: want %q, have %q", want, have)
+		}
+		break
+	}
+
+	<-closed
+	if want, have := StateConnecting, es.ReadyState(); want != have {
+		t.Errorf("ReadyState: want %v, have %v", want, have)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ids []string
+	for e, err := range es.AllContext(ctx) {
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				t.Fatal(err)
+			}
+			continue
+		}
+		ids = append(ids, e.ID)
+		if len(ids) == 2 {
+			cancel()
+		}
+	}
+
+	if want, have := []string{"2", "3"}, ids; !reflect.DeepEqual(want, have) {
+		t.Errorf("IDs: want %q, have %q", want, have)
+	}
+	if want, have := "", <-lastIDs; want != have {
+		t.Errorf("first Last-Event-Id: want %q, have %q", want, have)
+	}
+	if want, have := "1", <-lastIDs; want != have {
+		t.Errorf("second Last-Event-Id: want %q, have %q", want, have)
+	}
+	if err := es.Err(); err != nil {
+		t.Errorf("Err: want nil, have %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// EventReader is a stream of events, such as an EventSource or a Decoder.
+type EventReader interface {
+	ReadContext(ctx context.Context) (Event, error)
+}
+
+// EventHandler handles events dispatched by a Mux.
+type EventHandler interface {
+	HandleEvent(ctx context.Context, e Event) error
+}
+
+// EventHandlerFunc is an adapter for ordinary functions to act as an
+// EventHandler.
+type EventHandlerFunc func(ctx context.Context, e Event) error
+
+// HandleEvent calls f(ctx, e).
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Mux routes events to handlers according to their type, like
+// addEventListener in the browser EventSource API.
+//
+// Patterns are either an exact event type, like "message", or a prefix
+// followed by "*", like "user.*", which matches every type with that prefix.
+// An exact match is preferred, followed by the longest matching prefix. The
+// pattern "*" matches every type, and so acts as a fallback for events that
+// match no other pattern. Events that match no pattern are ignored.
+//
+// The zero value is an empty Mux, ready to use. Handlers must be registered
+// before events are dispatched.
+type Mux struct {
+	exact    map[string]EventHandler
+	prefixes []muxPrefix // longest first
+}
+
+type muxPrefix struct {
+	prefix  string
+	handler EventHandler
+}
+
+// Handle registers the handler for the given pattern. It panics if the
+// pattern is empty or already registered, or if the handler is nil.
+func (m *Mux) Handle(pattern string, h EventHandler) {
+	if pattern == "" {
+		panic("eventsource: empty pattern")
+	}
+	if h == nil {
+		panic("eventsource: nil handler for pattern " + pattern)
+	}
+
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		for _, p := range m.prefixes {
+			if p.prefix == prefix {
+				panic("eventsource: multiple registrations for pattern " + pattern)
+			}
+		}
+		m.prefixes = append(m.prefixes, muxPrefix{prefix: prefix, handler: h})
+		sort.SliceStable(m.prefixes, func(i, j int) bool {
+			return len(m.prefixes[i].prefix) > len(m.prefixes[j].prefix)
+		})
+		return
+	}
+
+	if _, ok := m.exact[pattern]; ok {
+		panic("eventsource: multiple registrations for pattern " + pattern)
+	}
+	if m.exact == nil {
+		m.exact = map[string]EventHandler{}
+	}
+	m.exact[pattern] = h
+}
+
+// HandleFunc registers the handler function for the given pattern.
+func (m *Mux) HandleFunc(pattern string, f func(ctx context.Context, e Event) error) {
+	m.Handle(pattern, EventHandlerFunc(f))
+}
+
+// Handler returns the handler for the given event type, or nil if no pattern
+// matches.
+func (m *Mux) Handler(eventType string) EventHandler {
+	if h, ok := m.exact[eventType]; ok {
+		return h
+	}
+	for _, p := range m.prefixes {
+		if strings.HasPrefix(eventType, p.prefix) {
+			return p.handler
+		}
+	}
+	return nil
+}
+
+// HandleEvent dispatches the event to the handler for its type, and returns
+// the handler's error, annotated with the event type. Mux therefore also
+// implements EventHandler, and can be nested.
+func (m *Mux) HandleEvent(ctx context.Context, e Event) error {
+	h := m.Handler(e.Type)
+	if h == nil {
+		return nil
+	}
+
+	if err := h.HandleEvent(ctx, e); err != nil {
+		return fmt.Errorf("handle %s event: %w", e.Type, err)
+	}
+
+	return nil
+}
+
+// Run reads events from r and dispatches them until ctx is done, r returns an
+// error, or a handler returns an error, and returns that error. If r returns
+// io.EOF, as a Decoder does at the end of its input, Run returns nil.
+func (m *Mux) Run(ctx context.Context, r EventReader) error {
+	for {
+		e, err := r.ReadContext(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := m.HandleEvent(ctx, e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMuxHandler(t *testing.T) {
+	t.Parallel()
+
+	var mux Mux
+	for _, pattern := range []string{"add", "user.*", "user.admin.*", "*"} {
+		mux.HandleFunc(pattern, func(context.Context, Event) error {
+			return errors.New(pattern)
+		})
+	}
+
+	for _, tt := range []struct {
+		eventType string
+		pattern   string
+	}{
+		{"add", "add"},
+		{"added", "*"},
+		{"user.created", "user.*"},
+		{"user.admin.created", "user.admin.*"},
+		{"user.", "user.*"},
+		{"user", "*"},
+		{"message", "*"},
+	} {
+		h := mux.Handler(tt.eventType)
+		if h == nil {
+			t.Errorf("%s: no handler", tt.eventType)
+			continue
+		}
+		if want, have := tt.pattern, h.HandleEvent(context.Background(), Event{}).Error(); want != have {
+			t.Errorf("%s: want pattern %q, have %q", tt.eventType, want, have)
+		}
+	}
+
+	var empty Mux
+	if h := empty.Handler("message"); h != nil {
+		t.Errorf("empty Mux: want nil handler, have %v", h)
+	}
+}
+
+func TestMuxRun(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(strings.NewReader("event: add\ndata: 1\n\nevent: remove\ndata: 2\n\nevent: other\ndata: 3\n\nevent: add\ndata: 4\n\n"))
+
+	var added, removed []string
+	var mux Mux
+	mux.HandleFunc("add", func(_ context.Context, e Event) error {
+		added = append(added, string(e.Data))
+		return nil
+	})
+	mux.HandleFunc("remove", func(_ context.Context, e Event) error {
+		removed = append(removed, string(e.Data))
+		return nil
+	})
+
+	if err := mux.Run(context.Background(), dec); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, have := []string{"1", "4"}, added; !reflect.DeepEqual(want, have) {
+		t.Errorf("added: want %q, have %q", want, have)
+	}
+	if want, have := []string{"2"}, removed; !reflect.DeepEqual(want, have) {
+		t.Errorf("removed: want %q, have %q", want, have)
+	}
+}
+
+func TestMuxRunHandlerError(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(strings.NewReader("event: add\ndata: 1\n\nevent: remove\ndata: 2\n\nevent: add\ndata: 3\n\n"))
+
+	errRemove := errors.New("remove failed")
+
+	var added int
+	var mux Mux
+	mux.HandleFunc("add", func(context.Context, Event) error {
+		added++
+		return nil
+	})
+	mux.HandleFunc("remove", func(context.Context, Event) error {
+		return errRemove
+	})
+
+	err := mux.Run(context.Background(), dec)
+	if !errors.Is(err, errRemove) {
+		t.Fatalf("want %v, have %v", errRemove, err)
+	}
+	if want, have := "handle remove event: remove failed", err.Error(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1, added; want != have {
+		t.Errorf("added: want %d, have %d", want, have)
+	}
+}
+
+func TestMuxHandlePanics(t *testing.T) {
+	t.Parallel()
+
+	for name, register := range map[string]func(*Mux){
+		"empty pattern":     func(m *Mux) { m.HandleFunc("", nil) },
+		"nil handler":       func(m *Mux) { m.Handle("add", nil) },
+		"duplicate exact":   func(m *Mux) { m.Handle("add", &Mux{}); m.Handle("add", &Mux{}) },
+		"duplicate prefix":  func(m *Mux) { m.Handle("user.*", &Mux{}); m.Handle("user.*", &Mux{}) },
+		"duplicate default": func(m *Mux) { m.Handle("*", &Mux{}); m.Handle("*", &Mux{}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+
+			register(&Mux{})
+		})
+	}
+}
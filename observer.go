@@ -0,0 +1,49 @@
+package eventsource
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer is notified about the connection lifecycle of an EventSource, for
+// logging, metrics, and alerting. Methods are called synchronously from the
+// goroutine calling Read, and should return quickly.
+type Observer interface {
+	// OnConnecting is called before every connection attempt, with the
+	// request that's about to be sent.
+	OnConnecting(req *http.Request)
+
+	// OnOpen is called when a response is accepted as an event stream.
+	OnOpen(resp *http.Response)
+
+	// OnDisconnect is called with the reason when a connection attempt fails,
+	// or when an open connection is lost or closed.
+	OnDisconnect(err error)
+
+	// OnRetry is called before waiting to reconnect, with the number of
+	// consecutive failures so far, and the delay chosen by the retry policy.
+	OnRetry(attempt int, delay time.Duration)
+
+	// OnInvalidEvent is called when an event is dropped because it couldn't
+	// be decoded, for example with ErrInvalidEncoding or ErrTooLarge.
+	OnInvalidEvent(err error)
+}
+
+// NopObserver is an Observer that does nothing. Embed it to implement only
+// some methods of Observer.
+type NopObserver struct{}
+
+// OnConnecting implements Observer.
+func (NopObserver) OnConnecting(*http.Request) {}
+
+// OnOpen implements Observer.
+func (NopObserver) OnOpen(*http.Response) {}
+
+// OnDisconnect implements Observer.
+func (NopObserver) OnDisconnect(error) {}
+
+// OnRetry implements Observer.
+func (NopObserver) OnRetry(int, time.Duration) {}
+
+// OnInvalidEvent implements Observer.
+func (NopObserver) OnInvalidEvent(error) {}
@@ -0,0 +1,97 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	NopObserver
+	calls []string
+}
+
+func (o *recordingObserver) OnConnecting(*http.Request) {
+	o.calls = append(o.calls, "connecting")
+}
+
+func (o *recordingObserver) OnOpen(resp *http.Response) {
+	o.calls = append(o.calls, fmt.Sprintf("open %d", resp.StatusCode))
+}
+
+func (o *recordingObserver) OnDisconnect(error) {
+	o.calls = append(o.calls, "disconnect")
+}
+
+func (o *recordingObserver) OnRetry(attempt int, delay time.Duration) {
+	o.calls = append(o.calls, fmt.Sprintf("retry %d %v", attempt, delay))
+}
+
+func (o *recordingObserver) OnInvalidEvent(err error) {
+	if errors.Is(err, ErrInvalidEncoding) {
+		o.calls = append(o.calls, "invalid encoding")
+		return
+	}
+	o.calls = append(o.calls, fmt.Sprintf("invalid %v", err))
+}
+
+func TestEventSourceObserver(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := testServer(func(w responseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		w.Write([]byte("data: \xFF\xFE\xFD\n\n"))
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	})
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	es := NewConfig(Config{
+		Request:  request(server.URL),
+		Retry:    time.Millisecond,
+		Observer: observer,
+	})
+	defer es.Close()
+
+	if _, err := es.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"connecting",
+		"disconnect",
+		"retry 1 1ms",
+		"connecting",
+		"open 200",
+		"invalid encoding",
+	}
+	if have := observer.calls; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want %q, have %q", want, have)
+	}
+
+	observer.calls = nil
+	if _, err := es.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	want = []string{
+		"disconnect",
+		"retry 1 1ms",
+		"connecting",
+		"open 200",
+		"invalid encoding",
+	}
+	if have := observer.calls; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want %q, have %q", want, have)
+	}
+}
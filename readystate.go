@@ -0,0 +1,45 @@
+package eventsource
+
+// ReadyState is the state of the connection of an EventSource, as defined by
+// the readyState attribute in the EventSource spec.
+type ReadyState int32
+
+const (
+	// StateConnecting means the connection hasn't been established yet, or
+	// was lost and the event source is reconnecting.
+	StateConnecting ReadyState = iota
+
+	// StateOpen means the connection is open, and events are being read.
+	StateOpen
+
+	// StateClosed means the event source is permanently closed.
+	StateClosed
+)
+
+// String implements fmt.Stringer.
+func (s ReadyState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateOpen:
+		return "open"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// Types of the synthetic events returned by Read when Config.StateEvents is
+// set. Synthetic events never have data, whereas events from the server
+// always do, so they can be distinguished even if the server sends events
+// with the same types.
+const (
+	// EventTypeOpen is the type of the event returned after every successful
+	// connection.
+	EventTypeOpen = "open"
+
+	// EventTypeError is the type of the event returned when an open
+	// connection is lost, before reconnecting.
+	EventTypeError = "error"
+)
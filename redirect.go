@@ -0,0 +1,147 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrTooManyRedirects indicates a connection attempt that was redirected
+// more than Config.MaxRedirects times.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// defaultMaxRedirects matches the limit of http.Client.
+const defaultMaxRedirects = 10
+
+// URL returns the effective URL of the current or most recent connection
+// attempt, after following any redirects, or nil if no attempt has been made.
+// It may be called from any goroutine.
+func (es *EventSource) URL() *url.URL {
+	u := es.url.Load()
+	if u == nil {
+		return nil
+	}
+	clone := *u
+	return &clone
+}
+
+// noFollow returns a copy of client that doesn't follow redirects, so that
+// the event source can follow them itself.
+func noFollow(client *http.Client) *http.Client {
+	clone := *client
+	clone.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &clone
+}
+
+// resolveMoved rewrites the URL of req according to permanent redirects seen
+// by earlier connection attempts. Like a redirect, it removes credentials if
+// the host changes.
+func (es *EventSource) resolveMoved(req *http.Request) {
+	host := req.URL.Host
+	for i := 0; i < es.maxRedirects; i++ {
+		u, ok := es.moved[req.URL.String()]
+		if !ok {
+			break
+		}
+		req.URL, req.Host = u, ""
+	}
+
+	if req.URL.Host != host {
+		removeCredentials(req.Header)
+	}
+}
+
+// do sends the request, and follows redirects up to the limit. Permanent
+// redirects are recorded, so that subsequent connection attempts go directly
+// to the new URL, as the EventSource spec requires.
+func (es *EventSource) do(req *http.Request) (*http.Response, error) {
+	for redirects := 0; ; redirects++ {
+		es.url.Store(req.URL)
+
+		resp, err := es.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Request != nil && resp.Request.URL != nil {
+			es.url.Store(resp.Request.URL) // the client may follow redirects itself
+		}
+
+		if es.maxRedirects < 0 || !isRedirect(resp.StatusCode) || resp.Header.Get("Location") == "" {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+
+		if redirects >= es.maxRedirects {
+			return nil, fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, redirects)
+		}
+
+		loc, err := req.URL.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			return nil, fmt.Errorf("parse redirect location: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusMovedPermanently || resp.StatusCode == http.StatusPermanentRedirect {
+			es.moved[req.URL.String()] = loc
+		}
+
+		if req, err = redirectRequest(req, loc, resp.StatusCode); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// redirectRequest returns the request to send to loc after a redirect with
+// the given status code, following the same rules as http.Client.
+func redirectRequest(req *http.Request, loc *url.URL, code int) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	next.URL, next.Host = loc, ""
+
+	switch {
+	case code == http.StatusTemporaryRedirect || code == http.StatusPermanentRedirect:
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, ErrBodyNotRewindable
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("get body: %w", err)
+			}
+			next.Body = body
+		}
+
+	case req.Method != http.MethodGet && req.Method != http.MethodHead:
+		next.Method = http.MethodGet
+		next.Body, next.GetBody, next.ContentLength = nil, nil, 0
+		next.Header.Del("Content-Type")
+		next.Header.Del("Content-Length")
+	}
+
+	if loc.Host != req.URL.Host {
+		removeCredentials(next.Header)
+	}
+
+	return next, nil
+}
+
+// removeCredentials removes the headers that http.Client doesn't forward to
+// other hosts, so that credentials don't leak.
+func removeCredentials(h http.Header) {
+	for _, k := range []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"} {
+		h.Del(k)
+	}
+}
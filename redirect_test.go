@@ -0,0 +1,207 @@
+package eventsource
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventSourceRedirect(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		code int
+		hits []string
+	}{
+		{http.StatusMovedPermanently, []string{"/old", "/new", "/new"}},
+		{http.StatusPermanentRedirect, []string{"/old", "/new", "/new"}},
+		{http.StatusFound, []string{"/old", "/new", "/old", "/new"}},
+		{http.StatusTemporaryRedirect, []string{"/old", "/new", "/old", "/new"}},
+	} {
+		t.Run(http.StatusText(tt.code), func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mtx  sync.Mutex
+				hits []string
+			)
+			server := testServer(func(w responseWriter, r *http.Request) {
+				mtx.Lock()
+				hits = append(hits, r.URL.Path)
+				mtx.Unlock()
+
+				if r.URL.Path == "/old" {
+					http.Redirect(w, r, "/new", tt.code)
+					return
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.WriteHeader(200)
+				NewEncoder(w).Encode(Event{Data: []byte("foo")})
+			})
+			defer server.Close()
+
+			es := New(request(server.URL+"/old"), time.Millisecond)
+			defer es.Close()
+
+			if u := es.URL(); u != nil {
+				t.Errorf("URL before connecting: want nil, have %s", u)
+			}
+
+			for i := 0; i < 2; i++ {
+				if _, err := es.Read(); err != nil {
+					t.Fatal(err)
+				}
+				if want, have := server.URL+"/new", es.URL().String(); want != have {
+					t.Errorf("URL: want %s, have %s", want, have)
+				}
+			}
+
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			if want, have := tt.hits, hits; !reflect.DeepEqual(want, have) {
+				t.Errorf("hits: want %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestEventSourceTooManyRedirects(t *testing.T) {
+	t.Parallel()
+
+	var hits int
+	server := testServer(func(w responseWriter, r *http.Request) {
+		hits++
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	defer server.Close()
+
+	es := NewConfig(Config{
+		Request:      request(server.URL),
+		MaxRedirects: 3,
+	})
+
+	if _, err := es.Read(); !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("want %v, have %v", ErrTooManyRedirects, err)
+	}
+	if want, have := 4, hits; want != have {
+		t.Errorf("hits: want %d, have %d", want, have)
+	}
+}
+
+func TestEventSourceRedirectRequest(t *testing.T) {
+	t.Parallel()
+
+	type received struct{ method, body, auth string }
+	results := make(chan received, 1)
+	target := testServer(func(w responseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		results <- received{r.Method, string(body), r.Header.Get("Authorization")}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	})
+	defer target.Close()
+
+	for _, tt := range []struct {
+		code int
+		want received
+	}{
+		{http.StatusTemporaryRedirect, received{"POST", "query", ""}},
+		{http.StatusSeeOther, received{"GET", "", ""}},
+	} {
+		origin := httptest.NewServer(http.RedirectHandler(target.URL, tt.code))
+		defer origin.Close()
+
+		req, _ := http.NewRequest("POST", origin.URL, strings.NewReader("query"))
+		req.Header.Set("Authorization", "Bearer secret")
+
+		es := New(req, time.Millisecond)
+		defer es.Close()
+
+		if _, err := es.Read(); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := tt.want, <-results; want != have {
+			t.Errorf("%d: want %+v, have %+v", tt.code, want, have)
+		}
+	}
+}
+
+func TestEventSourceRedirectOtherHost(t *testing.T) {
+	t.Parallel()
+
+	auths := make(chan string, 2)
+	target := testServer(func(w responseWriter, r *http.Request) {
+		auths <- r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	})
+	defer target.Close()
+
+	var hits int
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Redirect(w, r, target.URL, http.StatusMovedPermanently)
+	}))
+	defer origin.Close()
+
+	req := request(origin.URL)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	es := New(req, time.Millisecond)
+	defer es.Close()
+
+	for i := 0; i < 2; i++ { // the second read reconnects to the target directly
+		if _, err := es.Read(); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "", <-auths; want != have {
+			t.Errorf("%d: Authorization: want %q, have %q", i, want, have)
+		}
+	}
+	if want, have := 1, hits; want != have {
+		t.Errorf("origin hits: want %d, have %d", want, have)
+	}
+}
+
+func TestEventSourceNoRedirects(t *testing.T) {
+	t.Parallel()
+
+	var paths []string
+	server := testServer(func(w responseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+	})
+	defer server.Close()
+
+	es := NewConfig(Config{
+		Request:      request(server.URL + "/old"),
+		MaxRedirects: -1,
+	})
+
+	_, err := es.Read()
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("want *StatusError, have %T: %v", err, err)
+	}
+	if want, have := http.StatusFound, se.StatusCode; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := []string{"/old"}, paths; !reflect.DeepEqual(want, have) {
+		t.Errorf("paths: want %q, have %q", want, have)
+	}
+}
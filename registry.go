@@ -0,0 +1,115 @@
+package eventsource
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Registry maps event types to the Go types of their payloads, so that
+// streams carrying several kinds of events can be decoded into values of the
+// right concrete type, and values can be encoded as events of the right type.
+//
+// The zero value is an empty Registry using JSONCodec, ready to use. Types
+// must be registered with Register before events are decoded or encoded.
+type Registry struct {
+	// Codec encodes and decodes payloads. If nil, JSONCodec is used.
+	Codec Codec
+
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}
+
+// Register registers T as the payload type of events with the given type. It
+// panics if the event type is empty, or if the event type or T is already
+// registered.
+func Register[T any](r *Registry, eventType string) {
+	t := reflect.TypeFor[T]()
+
+	if eventType == "" {
+		panic("eventsource: empty event type for " + t.String())
+	}
+	if _, ok := r.types[eventType]; ok {
+		panic("eventsource: multiple registrations for event type " + eventType)
+	}
+	if _, ok := r.names[t]; ok {
+		panic("eventsource: multiple registrations for " + t.String())
+	}
+
+	if r.types == nil {
+		r.types, r.names = map[string]reflect.Type{}, map[reflect.Type]string{}
+	}
+	r.types[eventType], r.names[t] = t, eventType
+}
+
+// UnknownTypeError is returned by a Registry for events whose type isn't
+// registered. Like a *PayloadError, it doesn't affect the underlying stream.
+type UnknownTypeError struct {
+	Event Event
+}
+
+// Error implements the error interface.
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown event type %q", e.Event.Type)
+}
+
+func (r *Registry) codec() Codec {
+	if r.Codec == nil {
+		return JSONCodec{}
+	}
+	return r.Codec
+}
+
+// Decode the data of the event into a new value of the type registered for
+// the event type, and return it. If the event type isn't registered, an
+// *UnknownTypeError is returned; if the data can't be decoded, a
+// *PayloadError is returned.
+func (r *Registry) Decode(e Event) (any, error) {
+	t, ok := r.types[e.Type]
+	if !ok {
+		return nil, &UnknownTypeError{Event: e}
+	}
+
+	v := reflect.New(t)
+	if err := r.codec().Unmarshal(e.Data, v.Interface()); err != nil {
+		return nil, &PayloadError{Event: e, Err: err}
+	}
+
+	return v.Elem().Interface(), nil
+}
+
+// Read the next event from src, such as an EventSource or a Decoder, and
+// decode it with Decode. Errors from src are returned as is.
+func (r *Registry) Read(ctx context.Context, src EventReader) (any, Event, error) {
+	e, err := src.ReadContext(ctx)
+	if err != nil {
+		return nil, e, err
+	}
+
+	v, err := r.Decode(e)
+	return v, e, err
+}
+
+// Encode v as the data of the event, set the type of the event to the type
+// registered for the type of v, or the type v points to, and write the event
+// to enc. Any type or data already in the event is replaced. If the type of v
+// isn't registered, or v can't be encoded, nothing is written.
+func (r *Registry) Encode(enc *Encoder, v any, e Event) error {
+	t := reflect.TypeOf(v)
+	name, ok := r.names[t]
+	if !ok && t != nil && t.Kind() == reflect.Pointer {
+		name, ok = r.names[t.Elem()]
+	}
+	if !ok {
+		return fmt.Errorf("no event type registered for %T", v)
+	}
+
+	data, err := r.codec().Marshal(v)
+	if err != nil {
+		e.Type = name
+		return &PayloadError{Event: e, Err: err}
+	}
+
+	e.Type, e.Data = name, data
+	return enc.Encode(e)
+}
@@ -0,0 +1,91 @@
+package eventsource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+type userCreated struct {
+	Name string
+}
+
+type userDeleted struct {
+	ID int
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	var r Registry
+	Register[userCreated](&r, "user.created")
+	Register[userDeleted](&r, "user.deleted")
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, v := range []any{userCreated{"alice"}, &userDeleted{7}} {
+		if err := r.Encode(enc, v, Event{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Encode(enc, point{1, 2}, Event{}); err == nil {
+		t.Errorf("Encode unregistered type: want error, have nil")
+	}
+	enc.Encode(Event{Type: "user.renamed", Data: []byte("{}")})
+	enc.Encode(Event{Type: "user.deleted", Data: []byte("{")})
+
+	ctx := context.Background()
+	dec := NewDecoder(&buf)
+
+	for _, want := range []any{userCreated{"alice"}, userDeleted{7}} {
+		have, _, err := r.Read(ctx, dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != have {
+			t.Errorf("want %#v, have %#v", want, have)
+		}
+	}
+
+	_, _, err := r.Read(ctx, dec)
+	var ue *UnknownTypeError
+	if !errors.As(err, &ue) {
+		t.Fatalf("want *UnknownTypeError, have %T: %v", err, err)
+	}
+	if want, have := "user.renamed", ue.Event.Type; want != have {
+		t.Errorf("Type: want %q, have %q", want, have)
+	}
+
+	_, _, err = r.Read(ctx, dec)
+	var pe *PayloadError
+	if !errors.As(err, &pe) {
+		t.Fatalf("want *PayloadError, have %T: %v", err, err)
+	}
+
+	if _, _, err := r.Read(ctx, dec); !errors.Is(err, io.EOF) {
+		t.Errorf("want EOF, have %v", err)
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	t.Parallel()
+
+	for name, f := range map[string]func(r *Registry){
+		"empty type":     func(r *Registry) { Register[userCreated](r, "") },
+		"duplicate type": func(r *Registry) { Register[userDeleted](r, "user.created") },
+		"duplicate Go":   func(r *Registry) { Register[userCreated](r, "user.added") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("want panic, have none")
+				}
+			}()
+			var r Registry
+			Register[userCreated](&r, "user.created")
+			f(&r)
+		})
+	}
+}
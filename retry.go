@@ -0,0 +1,119 @@
+package eventsource
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides how long an EventSource waits before reconnecting, and
+// when it should give up.
+type RetryPolicy interface {
+	// Next returns the delay before the next connection attempt. If ok is
+	// false, the event source gives up, and Read returns a
+	// *RetriesExhaustedError.
+	Next(r Retry) (delay time.Duration, ok bool)
+}
+
+// Retry describes the state of a reconnecting EventSource.
+type Retry struct {
+	// Attempt is the number of consecutive failures so far, starting at 1.
+	// It's reset when a connection is successfully established.
+	Attempt int
+
+	// Err describes the most recent failure: a transport error, a read error
+	// from a connection that was lost, or an unsuccessful response.
+	Err error
+
+	// StatusCode is the status of the most recent response, or zero if the
+	// most recent failure didn't produce a response.
+	StatusCode int
+
+	// RetryAfter is the minimum delay requested by the most recent response,
+	// typically via a Retry-After header. The event source waits at least
+	// this long, regardless of the delay returned by the policy.
+	RetryAfter time.Duration
+
+	// Hint is the retry interval from Config.Retry, or from the most recent
+	// retry field sent by the server.
+	Hint time.Duration
+
+	// Elapsed is the time since the first of the consecutive failures.
+	Elapsed time.Duration
+}
+
+// ConstantBackoff waits the retry hint between every attempt. This is the
+// behavior described by the EventSource spec, and the default policy.
+type ConstantBackoff struct {
+	// MaxAttempts, if greater than zero, limits the number of consecutive
+	// failures before giving up.
+	MaxAttempts int
+
+	// MaxElapsed, if greater than zero, limits the time spent retrying
+	// consecutive failures before giving up.
+	MaxElapsed time.Duration
+}
+
+// Next implements RetryPolicy.
+func (b ConstantBackoff) Next(r Retry) (time.Duration, bool) {
+	if exhausted(r, b.MaxAttempts, b.MaxElapsed) {
+		return 0, false
+	}
+	return r.Hint, true
+}
+
+// ExponentialBackoff waits the retry hint after the first failure, and
+// doubles the delay after every subsequent failure, up to Max.
+type ExponentialBackoff struct {
+	// Max, if greater than zero, caps the delay.
+	Max time.Duration
+
+	// MaxAttempts, if greater than zero, limits the number of consecutive
+	// failures before giving up.
+	MaxAttempts int
+
+	// MaxElapsed, if greater than zero, limits the time spent retrying
+	// consecutive failures before giving up.
+	MaxElapsed time.Duration
+}
+
+// Next implements RetryPolicy.
+func (b ExponentialBackoff) Next(r Retry) (time.Duration, bool) {
+	if exhausted(r, b.MaxAttempts, b.MaxElapsed) {
+		return 0, false
+	}
+
+	delay := r.Hint
+	for i := 1; i < r.Attempt; i++ {
+		if delay > math.MaxInt64/2 || (b.Max > 0 && delay >= b.Max) {
+			break
+		}
+		delay *= 2
+	}
+
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	return delay, true
+}
+
+// FullJitter wraps another RetryPolicy, typically ExponentialBackoff, and
+// waits a uniformly random duration between zero and the wrapped policy's
+// delay, so that many clients don't reconnect in lockstep.
+type FullJitter struct {
+	RetryPolicy
+}
+
+// Next implements RetryPolicy.
+func (j FullJitter) Next(r Retry) (time.Duration, bool) {
+	delay, ok := j.RetryPolicy.Next(r)
+	if !ok || delay <= 0 {
+		return delay, ok
+	}
+	return rand.N(delay + 1), true
+}
+
+func exhausted(r Retry, maxAttempts int, maxElapsed time.Duration) bool {
+	return (maxAttempts > 0 && r.Attempt > maxAttempts) || (maxElapsed > 0 && r.Elapsed >= maxElapsed)
+}
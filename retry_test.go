@@ -0,0 +1,117 @@
+package eventsource
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicies(t *testing.T) {
+	t.Parallel()
+
+	for i, tt := range []struct {
+		policy RetryPolicy
+		retry  Retry
+		delay  time.Duration
+		ok     bool
+	}{
+		{ConstantBackoff{}, Retry{Attempt: 1, Hint: time.Second}, time.Second, true},
+		{ConstantBackoff{}, Retry{Attempt: 100, Hint: time.Second}, time.Second, true},
+		{ConstantBackoff{MaxAttempts: 3}, Retry{Attempt: 3, Hint: time.Second}, time.Second, true},
+		{ConstantBackoff{MaxAttempts: 3}, Retry{Attempt: 4, Hint: time.Second}, 0, false},
+		{ConstantBackoff{MaxElapsed: time.Minute}, Retry{Attempt: 1, Elapsed: time.Minute}, 0, false},
+		{ExponentialBackoff{}, Retry{Attempt: 1, Hint: time.Second}, time.Second, true},
+		{ExponentialBackoff{}, Retry{Attempt: 4, Hint: time.Second}, 8 * time.Second, true},
+		{ExponentialBackoff{Max: 5 * time.Second}, Retry{Attempt: 4, Hint: time.Second}, 5 * time.Second, true},
+		{ExponentialBackoff{Max: 5 * time.Second}, Retry{Attempt: 1000, Hint: time.Second}, 5 * time.Second, true},
+		{ExponentialBackoff{}, Retry{Attempt: 1000, Hint: time.Second}, time.Second << 33, true},
+		{ExponentialBackoff{MaxAttempts: 2}, Retry{Attempt: 3, Hint: time.Second}, 0, false},
+		{FullJitter{ExponentialBackoff{MaxAttempts: 2}}, Retry{Attempt: 3, Hint: time.Second}, 0, false},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			delay, ok := tt.policy.Next(tt.retry)
+			if want, have := tt.ok, ok; want != have {
+				t.Errorf("ok: want %v, have %v", want, have)
+			}
+			if want, have := tt.delay, delay; want != have {
+				t.Errorf("delay: want %v, have %v", want, have)
+			}
+		})
+	}
+}
+
+func TestFullJitter(t *testing.T) {
+	t.Parallel()
+
+	policy := FullJitter{ExponentialBackoff{Max: 10 * time.Second}}
+
+	for attempt := 1; attempt < 100; attempt++ {
+		delay, ok := policy.Next(Retry{Attempt: attempt, Hint: time.Second})
+		if !ok {
+			t.Fatalf("attempt %d: gave up", attempt)
+		}
+		if delay < 0 || delay > 10*time.Second {
+			t.Fatalf("attempt %d: delay %v out of range", attempt, delay)
+		}
+	}
+}
+
+func TestEventSourceRetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			attempts++
+			w.WriteHeader(503)
+		}),
+	)
+	defer server.Close()
+
+	var retries []Retry
+	es := NewConfig(Config{
+		Request: request(server.URL),
+		Retry:   time.Millisecond,
+		RetryPolicy: retryPolicyFunc(func(r Retry) (time.Duration, bool) {
+			retries = append(retries, r)
+			return ExponentialBackoff{MaxAttempts: 3}.Next(r)
+		}),
+	})
+
+	_, err := es.Read()
+
+	var exhausted *RetriesExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("want %T, have %v", exhausted, err)
+	}
+	if want, have := 4, exhausted.Attempts; want != have {
+		t.Errorf("Attempts: want %d, have %d", want, have)
+	}
+	if want, have := 4, attempts; want != have {
+		t.Errorf("requests: want %d, have %d", want, have)
+	}
+	if want, have := 4, len(retries); want != have {
+		t.Fatalf("calls to Next: want %d, have %d", want, have)
+	}
+	for i, r := range retries {
+		if want, have := i+1, r.Attempt; want != have {
+			t.Errorf("%d: Attempt: want %d, have %d", i, want, have)
+		}
+		if want, have := 503, r.StatusCode; want != have {
+			t.Errorf("%d: StatusCode: want %d, have %d", i, want, have)
+		}
+		if want, have := time.Millisecond, r.Hint; want != have {
+			t.Errorf("%d: Hint: want %v, have %v", i, want, have)
+		}
+	}
+	if _, err := es.Read(); !errors.As(err, &exhausted) {
+		t.Errorf("subsequent Read: want %T, have %v", exhausted, err)
+	}
+}
+
+type retryPolicyFunc func(Retry) (time.Duration, bool)
+
+func (f retryPolicyFunc) Next(r Retry) (time.Duration, bool) { return f(r) }
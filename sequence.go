@@ -0,0 +1,132 @@
+package eventsource
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Sequencing configures duplicate suppression and gap detection for an
+// EventSource. Servers commonly replay some events around the Last-Event-Id
+// after a reconnect, and may skip events that are no longer available.
+// Events without an ID are never suppressed, and an event that resets the ID
+// forgets every ID seen before it. With Config.ManualCommit, IDs
+// read after the last commit are forgotten when reconnecting, so that the
+// events redelivered by the server aren't suppressed.
+type Sequencing struct {
+	// Window is the number of recently seen event IDs that are remembered to
+	// suppress duplicates. Zero disables suppression by window.
+	Window int
+
+	// Parse, if set, parses event IDs as monotonically increasing sequence
+	// numbers. An event whose sequence number isn't greater than the highest
+	// seen so far is suppressed as a duplicate, and an event whose sequence
+	// number skips ahead is preceded by a *GapError. Events whose IDs fail to
+	// parse are only subject to the window. ParseNumericID parses decimal
+	// IDs.
+	Parse func(id string) (uint64, error)
+}
+
+// ParseNumericID parses decimal event IDs. It can be used as Sequencing.Parse.
+func ParseNumericID(id string) (uint64, error) {
+	return strconv.ParseUint(id, 10, 64)
+}
+
+// GapError is returned by Read when Sequencing detects missing events. It
+// doesn't close the event source: the event that revealed the gap is returned
+// by the next call to Read.
+type GapError struct {
+	// LastID is the ID of the last event seen before the gap.
+	LastID string
+
+	// NextID is the ID of the event after the gap.
+	NextID string
+
+	// Missing is the number of events missing between them.
+	Missing uint64
+}
+
+// Error implements the error interface.
+func (e *GapError) Error() string {
+	return fmt.Sprintf("%d event(s) missing between IDs %s and %s", e.Missing, e.LastID, e.NextID)
+}
+
+// sequencer tracks event IDs according to a Sequencing configuration.
+type sequencer struct {
+	Sequencing
+
+	recent []string // the last Window IDs, oldest first
+	seen   map[string]struct{}
+
+	lastID  string
+	lastSeq uint64
+	hasSeq  bool
+}
+
+func newSequencer(config Sequencing) *sequencer {
+	return &sequencer{
+		Sequencing: config,
+		seen:       map[string]struct{}{},
+	}
+}
+
+// check records the ID of the event, and reports whether the event is a
+// duplicate, or follows a gap.
+func (s *sequencer) check(e Event) (duplicate bool, gap *GapError) {
+	if len(e.ID) == 0 {
+		if e.ResetID {
+			s.rewind("") // the server starts over, so forget every ID seen so far
+		}
+		return false, nil
+	}
+
+	if _, ok := s.seen[e.ID]; ok {
+		return true, nil
+	}
+
+	if s.Parse != nil {
+		if seq, err := s.Parse(e.ID); err == nil {
+			if s.hasSeq && seq <= s.lastSeq {
+				return true, nil
+			}
+			if s.hasSeq && seq > s.lastSeq+1 {
+				gap = &GapError{LastID: s.lastID, NextID: e.ID, Missing: seq - s.lastSeq - 1}
+			}
+			s.lastID, s.lastSeq, s.hasSeq = e.ID, seq, true
+		}
+	}
+
+	s.remember(e.ID)
+
+	return false, gap
+}
+
+func (s *sequencer) remember(id string) {
+	if s.Window <= 0 {
+		return
+	}
+
+	if len(s.recent) == s.Window {
+		delete(s.seen, s.recent[0])
+		s.recent = s.recent[1:]
+	}
+
+	s.recent = append(s.recent, id)
+	s.seen[id] = struct{}{}
+}
+
+// rewind forgets every ID recorded after the given ID, so that events which
+// were read but not committed aren't suppressed when the server delivers them
+// again. If the ID isn't remembered, the whole window is forgotten.
+func (s *sequencer) rewind(id string) {
+	for len(s.recent) > 0 && s.recent[len(s.recent)-1] != id {
+		delete(s.seen, s.recent[len(s.recent)-1])
+		s.recent = s.recent[:len(s.recent)-1]
+	}
+
+	s.lastID, s.lastSeq, s.hasSeq = id, 0, false
+	if s.Parse != nil && len(id) > 0 {
+		if seq, err := s.Parse(id); err == nil {
+			s.lastSeq, s.hasSeq = seq, true
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSequencerWindow(t *testing.T) {
+	t.Parallel()
+
+	s := newSequencer(Sequencing{Window: 2})
+
+	for i, tt := range []struct {
+		id        string
+		duplicate bool
+	}{
+		{"a", false},
+		{"b", false},
+		{"a", true},
+		{"", false},
+		{"", false},
+		{"c", false}, // evicts a
+		{"b", true},
+		{"a", false},
+		{"c", true},
+	} {
+		duplicate, gap := s.check(Event{ID: tt.id})
+		if want, have := tt.duplicate, duplicate; want != have {
+			t.Errorf("%d. %q: duplicate: want %v, have %v", i, tt.id, want, have)
+		}
+		if gap != nil {
+			t.Errorf("%d. %q: unexpected gap %v", i, tt.id, gap)
+		}
+	}
+}
+
+func TestSequencerParse(t *testing.T) {
+	t.Parallel()
+
+	s := newSequencer(Sequencing{Parse: ParseNumericID})
+
+	for i, tt := range []struct {
+		id        string
+		duplicate bool
+		gap       *GapError
+	}{
+		{"1", false, nil},
+		{"2", false, nil},
+		{"2", true, nil},
+		{"1", true, nil},
+		{"5", false, &GapError{LastID: "2", NextID: "5", Missing: 2}},
+		{"x", false, nil},
+		{"6", false, nil},
+		{"4", true, nil},
+	} {
+		duplicate, gap := s.check(Event{ID: tt.id})
+		if want, have := tt.duplicate, duplicate; want != have {
+			t.Errorf("%d. %q: duplicate: want %v, have %v", i, tt.id, want, have)
+		}
+		if want, have := tt.gap, gap; !reflect.DeepEqual(want, have) {
+			t.Errorf("%d. %q: gap: want %v, have %v", i, tt.id, want, have)
+		}
+	}
+}
+
+func TestSequencerResetID(t *testing.T) {
+	t.Parallel()
+
+	s := newSequencer(Sequencing{Window: 8, Parse: ParseNumericID})
+
+	for i, tt := range []struct {
+		event     Event
+		duplicate bool
+		gap       *GapError
+	}{
+		{Event{ID: "5"}, false, nil},
+		{Event{ID: "6"}, false, nil},
+		{Event{ResetID: true}, false, nil},
+		{Event{ID: "1"}, false, nil},
+		{Event{ID: "2"}, false, nil},
+		{Event{ID: "2"}, true, nil},
+		{Event{ID: "5"}, false, &GapError{LastID: "2", NextID: "5", Missing: 2}},
+		{Event{ID: "6"}, false, nil},
+	} {
+		duplicate, gap := s.check(tt.event)
+		if want, have := tt.duplicate, duplicate; want != have {
+			t.Errorf("%d. %q: duplicate: want %v, have %v", i, tt.event.ID, want, have)
+		}
+		if want, have := tt.gap, gap; !reflect.DeepEqual(want, have) {
+			t.Errorf("%d. %q: gap: want %v, have %v", i, tt.event.ID, want, have)
+		}
+	}
+}
+
+func TestEventSourceSequencing(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+
+		ids := []int{1, 2, 3}
+		if r.Header.Get("Last-Event-Id") != "" {
+			ids = []int{2, 3, 4, 7} // replays 2 and 3, loses 5 and 6
+		}
+		for _, id := range ids {
+			fmt.Fprintf(w, "id: %d\ndata: foo\n\n", id)
+		}
+		w.Flush()
+
+		if len(ids) == 4 {
+			<-r.Context().Done()
+		}
+	})
+	defer server.Close()
+
+	es := NewConfig(Config{
+		Request:    request(server.URL),
+		Retry:      time.Millisecond,
+		Sequencing: &Sequencing{Parse: ParseNumericID},
+	})
+	defer es.Close()
+
+	var ids []string
+	for len(ids) < 5 {
+		event, err := es.Read()
+
+		var gap *GapError
+		if errors.As(err, &gap) {
+			ids = append(ids, "gap "+strconv.FormatUint(gap.Missing, 10))
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, event.ID)
+	}
+
+	event, err := es.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids = append(ids, event.ID)
+
+	if want, have := []string{"1", "2", "3", "4", "gap 2", "7"}, ids; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "7", es.LastEventID(); want != have {
+		t.Errorf("LastEventID: want %q, have %q", want, have)
+	}
+}
+
+func TestSequencerRewind(t *testing.T) {
+	t.Parallel()
+
+	s := newSequencer(Sequencing{Window: 10, Parse: ParseNumericID})
+	for _, id := range []string{"1", "2", "3"} {
+		s.check(Event{ID: id})
+	}
+
+	s.rewind("1")
+
+	for i, tt := range []struct {
+		id        string
+		duplicate bool
+	}{
+		{"1", true},
+		{"2", false},
+		{"3", false},
+		{"3", true},
+	} {
+		duplicate, gap := s.check(Event{ID: tt.id})
+		if want, have := tt.duplicate, duplicate; want != have {
+			t.Errorf("%d. %q: duplicate: want %v, have %v", i, tt.id, want, have)
+		}
+		if gap != nil {
+			t.Errorf("%d. %q: unexpected gap %v", i, tt.id, gap)
+		}
+	}
+}
+
+func TestEventSourceSequencingManualCommit(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+
+		id, _ := strconv.Atoi(r.Header.Get("Last-Event-Id"))
+		for i := id + 1; i <= id+2; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: foo\n\n", i)
+		}
+	})
+	defer server.Close()
+
+	es := NewConfig(Config{
+		Request:      request(server.URL),
+		Retry:        time.Millisecond,
+		ManualCommit: true,
+		Sequencing:   &Sequencing{Window: 10, Parse: ParseNumericID},
+	})
+	defer es.Close()
+
+	var ids []string
+	for _, commit := range []bool{true, false, true, true} {
+		event, err := es.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, event.ID)
+		if commit {
+			if err := es.Commit(event); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if want, have := []string{"1", "2", "2", "3"}, ids; !reflect.DeepEqual(want, have) {
+		t.Errorf("IDs: want %q, have %q", want, have)
+	}
+}
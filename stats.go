@@ -0,0 +1,119 @@
+package eventsource
+
+import (
+	"expvar"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the counters maintained by an EventSource.
+type Stats struct {
+	// ConnectionsAttempted is the number of requests sent to connect.
+	ConnectionsAttempted int64
+
+	// ConnectionsSucceeded is the number of connections that were opened.
+	ConnectionsSucceeded int64
+
+	// Reconnects is the number of connections that were opened after the
+	// first one.
+	Reconnects int64
+
+	// EventsDelivered is the number of events returned by Read.
+	EventsDelivered int64
+
+	// BytesRead is the number of bytes read from all connections.
+	BytesRead int64
+
+	// InvalidEvents is the number of events dropped because of invalid
+	// encoding, or because they exceeded a limit of the DecoderConfig.
+	InvalidEvents int64
+
+	// RetryDelay is the delay before the current connection attempt, or zero
+	// once a connection is open.
+	RetryDelay time.Duration
+
+	// LastError is the most recent error that failed a connection attempt, or
+	// closed an open connection or the event source, if any.
+	LastError error
+
+	// LastEventAt is the time the most recent event was returned by Read, or
+	// the zero time if no event was returned yet.
+	LastEventAt time.Time
+}
+
+// stats holds the counters behind Stats, so that they can be read from any
+// goroutine.
+type stats struct {
+	attempted   atomic.Int64
+	succeeded   atomic.Int64
+	reconnects  atomic.Int64
+	delivered   atomic.Int64
+	bytes       atomic.Int64
+	invalid     atomic.Int64
+	retryDelay  atomic.Int64
+	lastErr     atomic.Pointer[error]
+	lastEventAt atomic.Int64
+}
+
+// Stats returns a snapshot of the counters of the event source. It may be
+// called from any goroutine.
+func (es *EventSource) Stats() Stats {
+	s := Stats{
+		ConnectionsAttempted: es.stats.attempted.Load(),
+		ConnectionsSucceeded: es.stats.succeeded.Load(),
+		Reconnects:           es.stats.reconnects.Load(),
+		EventsDelivered:      es.stats.delivered.Load(),
+		BytesRead:            es.stats.bytes.Load(),
+		InvalidEvents:        es.stats.invalid.Load(),
+		RetryDelay:           time.Duration(es.stats.retryDelay.Load()),
+	}
+	if err := es.stats.lastErr.Load(); err != nil {
+		s.LastError = *err
+	}
+	if t := es.stats.lastEventAt.Load(); t != 0 {
+		s.LastEventAt = time.Unix(0, t)
+	}
+	return s
+}
+
+// PublishExpvar publishes the stats of the event source as an expvar with the
+// given name, so that they're served by the expvar handler. Like
+// expvar.Publish, it panics if the name is already in use.
+func (es *EventSource) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		s := es.Stats()
+		v := map[string]any{
+			"ConnectionsAttempted": s.ConnectionsAttempted,
+			"ConnectionsSucceeded": s.ConnectionsSucceeded,
+			"Reconnects":           s.Reconnects,
+			"EventsDelivered":      s.EventsDelivered,
+			"BytesRead":            s.BytesRead,
+			"InvalidEvents":        s.InvalidEvents,
+			"RetryDelay":           s.RetryDelay.String(),
+			"LastError":            "",
+			"LastEventAt":          s.LastEventAt,
+		}
+		if s.LastError != nil {
+			v["LastError"] = s.LastError.Error()
+		}
+		return v
+	}))
+}
+
+// setErr records the most recent error.
+func (s *stats) setErr(err error) {
+	s.lastErr.Store(&err)
+}
+
+// countingReader counts the bytes read from a connection.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
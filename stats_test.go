@@ -0,0 +1,101 @@
+package eventsource
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventSourceStats(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := testServer(func(w responseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		if requests == 1 {
+			w.Write([]byte("data: \xFF\xFE\xFD\n\n"))
+			NewEncoder(w).Encode(Event{Data: []byte("foo")})
+			return
+		}
+		NewEncoder(w).Encode(Event{Data: []byte("bar")})
+		w.Flush()
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	defer es.Close()
+
+	if want, have := (Stats{}), es.Stats(); want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+
+	begin := time.Now()
+	for range 2 {
+		if _, err := es.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := es.Stats()
+	if want, have := int64(3), s.ConnectionsAttempted; want != have {
+		t.Errorf("ConnectionsAttempted: want %d, have %d", want, have)
+	}
+	if want, have := int64(2), s.ConnectionsSucceeded; want != have {
+		t.Errorf("ConnectionsSucceeded: want %d, have %d", want, have)
+	}
+	if want, have := int64(1), s.Reconnects; want != have {
+		t.Errorf("Reconnects: want %d, have %d", want, have)
+	}
+	if want, have := int64(2), s.EventsDelivered; want != have {
+		t.Errorf("EventsDelivered: want %d, have %d", want, have)
+	}
+	if want, have := int64(len("data: \xFF\xFE\xFD\n\ndata: foo\n\ndata: bar\n\n")), s.BytesRead; want != have {
+		t.Errorf("BytesRead: want %d, have %d", want, have)
+	}
+	if want, have := int64(1), s.InvalidEvents; want != have {
+		t.Errorf("InvalidEvents: want %d, have %d", want, have)
+	}
+	if want, have := time.Duration(0), s.RetryDelay; want != have {
+		t.Errorf("RetryDelay: want %v, have %v", want, have)
+	}
+	var se *StatusError
+	if !errors.As(s.LastError, &se) || se.StatusCode != 503 {
+		t.Errorf("LastError: want 503 *StatusError, have %v", s.LastError)
+	}
+	if s.LastEventAt.Before(begin) {
+		t.Errorf("LastEventAt: want after %v, have %v", begin, s.LastEventAt)
+	}
+
+	es.Close()
+	if want, have := s.LastError, es.Stats().LastError; want != have {
+		t.Errorf("LastError after Close: want %v, have %v", want, have)
+	}
+}
+
+func TestEventSourcePublishExpvar(t *testing.T) {
+	t.Parallel()
+
+	es := New(request("http://localhost"), time.Millisecond)
+	es.Close()
+
+	name := fmt.Sprintf("eventsource_test_%p", es)
+	es.PublishExpvar(name)
+
+	v := expvar.Get(name).String()
+	for _, want := range []string{`"ConnectionsAttempted":0`, `"LastError":""`, `"RetryDelay":"0s"`} {
+		if !strings.Contains(v, want) {
+			t.Errorf("want %s in %s", want, v)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LastEventIDStore persists the ID of the last event received by an
+// EventSource, so that it can resume from the same point after the process
+// restarts.
+type LastEventIDStore interface {
+	// Load returns the stored ID, or an empty string if none is stored.
+	Load() (string, error)
+
+	// Save stores the ID, replacing any previously stored ID.
+	Save(id string) error
+}
+
+// MemoryStore is a LastEventIDStore that keeps the ID in memory. It's safe
+// for concurrent use, and the zero value is an empty store.
+type MemoryStore struct {
+	mtx sync.Mutex
+	id  string
+}
+
+// Load implements LastEventIDStore.
+func (s *MemoryStore) Load() (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.id, nil
+}
+
+// Save implements LastEventIDStore.
+func (s *MemoryStore) Save(id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.id = id
+	return nil
+}
+
+// FileStore is a LastEventIDStore that keeps the ID in a file. Saves are
+// atomic: the ID is written to a temporary file in the same directory, which
+// then replaces the file at Path.
+type FileStore struct {
+	Path string
+}
+
+// Load implements LastEventIDStore. A missing file is treated as an empty
+// store.
+func (s FileStore) Load() (string, error) {
+	buf, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// Save implements LastEventIDStore.
+func (s FileStore) Save(id string) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := f.WriteString(id); err != nil {
+		return fmt.Errorf("write temp file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), s.Path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	return nil
+}
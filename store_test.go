@@ -0,0 +1,203 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := FileStore{Path: filepath.Join(dir, "last-event-id")}
+
+	if id, err := store.Load(); err != nil || id != "" {
+		t.Fatalf("Load of missing file: want %q, <nil>, have %q, %v", "", id, err)
+	}
+
+	for _, id := range []string{"1", "abc-123", ""} {
+		if err := store.Save(id); err != nil {
+			t.Fatalf("Save(%q): %v", id, err)
+		}
+		have, err := store.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if want := id; want != have {
+			t.Errorf("Load: want %q, have %q", want, have)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, len(entries); want != have {
+		t.Errorf("files in directory: want %d, have %d", want, have)
+	}
+}
+
+func TestFileStoreSaveError(t *testing.T) {
+	t.Parallel()
+
+	store := FileStore{Path: filepath.Join(t.TempDir(), "missing", "last-event-id")}
+	if err := store.Save("1"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEventSourceLastEventIDStore(t *testing.T) {
+	t.Parallel()
+
+	lastIDs := make(chan string, 1)
+	server := testServer(func(w responseWriter, r *http.Request) {
+		lastIDs <- r.Header.Get("Last-Event-Id")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+
+		id, _ := strconv.Atoi(r.Header.Get("Last-Event-Id"))
+		for i := id + 1; i <= id+2; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: foo\n\n", i)
+		}
+		w.Flush()
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	store := &MemoryStore{}
+	store.Save("5")
+
+	es := NewConfig(Config{
+		Request:          request(server.URL),
+		LastEventIDStore: store,
+	})
+	defer es.Close()
+
+	for _, want := range []string{"6", "7"} {
+		event, err := es.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := event.ID; want != have {
+			t.Errorf("ID: want %q, have %q", want, have)
+		}
+		if have, _ := store.Load(); want != have {
+			t.Errorf("stored ID: want %q, have %q", want, have)
+		}
+	}
+
+	if want, have := "5", <-lastIDs; want != have {
+		t.Errorf("Last-Event-Id: want %q, have %q", want, have)
+	}
+}
+
+type failingStore struct {
+	loadErr, saveErr error
+}
+
+func (s failingStore) Load() (string, error) { return "", s.loadErr }
+func (s failingStore) Save(string) error     { return s.saveErr }
+
+func TestEventSourceLastEventIDStoreErrors(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{ID: "1", Data: []byte("foo")})
+	})
+	defer server.Close()
+
+	for name, store := range map[string]failingStore{
+		"load": {loadErr: errors.New("load failed")},
+		"save": {saveErr: errors.New("save failed")},
+	} {
+		es := NewConfig(Config{
+			Request:          request(server.URL),
+			Retry:            time.Millisecond,
+			LastEventIDStore: store,
+		})
+
+		_, err := es.Read()
+		if want := store.loadErr; want != nil && !errors.Is(err, want) {
+			t.Errorf("%s: want %v, have %v", name, want, err)
+		}
+		if want := store.saveErr; want != nil && !errors.Is(err, want) {
+			t.Errorf("%s: want %v, have %v", name, want, err)
+		}
+		if want, have := err, es.Err(); want != have {
+			t.Errorf("%s: Err: want %v, have %v", name, want, have)
+		}
+	}
+}
+
+func TestEventSourceManualCommit(t *testing.T) {
+	t.Parallel()
+
+	lastIDs := make(chan string, 2)
+	server := testServer(func(w responseWriter, r *http.Request) {
+		lastIDs <- r.Header.Get("Last-Event-Id")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+
+		id, _ := strconv.Atoi(r.Header.Get("Last-Event-Id"))
+		for i := id + 1; i <= id+2; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: foo\n\n", i)
+		}
+	})
+	defer server.Close()
+
+	store := &MemoryStore{}
+	es := NewConfig(Config{
+		Request:          request(server.URL),
+		Retry:            time.Millisecond,
+		LastEventIDStore: store,
+		ManualCommit:     true,
+	})
+	defer es.Close()
+
+	read := func(want string) Event {
+		t.Helper()
+		event, err := es.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := event.ID; want != have {
+			t.Fatalf("ID: want %q, have %q", want, have)
+		}
+		return event
+	}
+
+	if err := es.Commit(read("1")); err != nil {
+		t.Fatal(err)
+	}
+	read("2") // not committed, so redelivered after reconnecting
+
+	if want, have := "1", es.LastEventID(); want != have {
+		t.Errorf("LastEventID: want %q, have %q", want, have)
+	}
+	if have, _ := store.Load(); have != "1" {
+		t.Errorf("stored ID: want %q, have %q", "1", have)
+	}
+
+	if err := es.Commit(read("2")); err != nil {
+		t.Fatal(err)
+	}
+	read("3")
+
+	if want, have := "2", es.LastEventID(); want != have {
+		t.Errorf("LastEventID: want %q, have %q", want, have)
+	}
+	if want, have := "", <-lastIDs; want != have {
+		t.Errorf("first Last-Event-Id: want %q, have %q", want, have)
+	}
+	if want, have := "1", <-lastIDs; want != have {
+		t.Errorf("second Last-Event-Id: want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,165 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// Overflow is what a Subscription does with an event when its buffer is full.
+type Overflow int
+
+const (
+	// OverflowBlock stops reading from the event source until the consumer
+	// receives from the events channel. It's the default.
+	OverflowBlock Overflow = iota
+
+	// OverflowDropNewest discards the event that doesn't fit in the buffer.
+	OverflowDropNewest
+
+	// OverflowDropOldest discards the oldest buffered event to make room for
+	// the new one. With an unbuffered channel, it behaves like
+	// OverflowDropNewest.
+	OverflowDropOldest
+)
+
+// SubscribeConfig enumerates the input parameters for a Subscription.
+type SubscribeConfig struct {
+	// BufferSize is the capacity of the events channel. Zero means the
+	// channel is unbuffered.
+	BufferSize int
+
+	// Overflow decides what happens when the events channel is full.
+	Overflow Overflow
+
+	// OnGap, if set, is called from the subscription's goroutine when
+	// Sequencing detects missing events, before the event after the gap is
+	// delivered. Gaps don't end the subscription.
+	OnGap func(gap *GapError)
+}
+
+// Subscription delivers events read from an EventSource on a channel.
+type Subscription struct {
+	events   chan Event
+	done     chan struct{}
+	err      error
+	overflow Overflow
+	onGap    func(*GapError)
+	dropped  atomic.Uint64
+}
+
+// Subscribe reads events from the event source in a new goroutine, and
+// delivers them on the returned subscription's events channel. The channel is
+// closed when ctx is done, or when the event source is closed, after which Err
+// returns the reason. Gaps detected by Sequencing don't end the subscription;
+// they're reported to SubscribeConfig.OnGap.
+//
+// The subscription reads from the event source until it ends, so Read must
+// not be called concurrently. Canceling ctx stops the subscription without
+// closing the event source.
+func (es *EventSource) Subscribe(ctx context.Context, config SubscribeConfig) *Subscription {
+	if config.BufferSize < 0 {
+		config.BufferSize = 0
+	}
+
+	s := &Subscription{
+		events:   make(chan Event, config.BufferSize),
+		done:     make(chan struct{}),
+		overflow: config.Overflow,
+		onGap:    config.OnGap,
+	}
+
+	go s.run(ctx, es)
+
+	return s
+}
+
+func (s *Subscription) run(ctx context.Context, es *EventSource) {
+	defer close(s.events)
+	defer close(s.done)
+
+	for {
+		e, err := es.ReadContext(ctx)
+		var gap *GapError
+		if errors.As(err, &gap) {
+			if s.onGap != nil {
+				s.onGap(gap)
+			}
+			continue
+		}
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		if err := s.deliver(ctx, es, e); err != nil {
+			s.err = err
+			return
+		}
+	}
+}
+
+func (s *Subscription) deliver(ctx context.Context, es *EventSource, e Event) error {
+	switch {
+	case s.overflow == OverflowBlock:
+		select {
+		case s.events <- e:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-es.Done():
+			return es.Err()
+		}
+
+	case s.overflow == OverflowDropOldest && cap(s.events) > 0:
+		for {
+			select {
+			case s.events <- e:
+				return nil
+			default:
+			}
+
+			select {
+			case <-s.events:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case s.events <- e:
+		default:
+			s.dropped.Add(1)
+		}
+		return nil
+	}
+}
+
+// Events returns the channel on which events are delivered. It's closed when
+// the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done returns a channel that's closed when the subscription ends.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns nil while the subscription is running. After it ends, Err
+// returns the reason: the error from Read, or the error of the context passed
+// to Subscribe.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Dropped returns the number of events discarded due to the overflow policy.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
@@ -0,0 +1,205 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := testServer(func(w responseWriter, _ *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(204)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: foo\n\n", i)
+		}
+	})
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	sub := es.Subscribe(context.Background(), SubscribeConfig{BufferSize: 1})
+
+	var ids []string
+	for e := range sub.Events() {
+		ids = append(ids, e.ID)
+	}
+
+	if want, have := []string{"1", "2", "3"}, ids; !reflect.DeepEqual(want, have) {
+		t.Errorf("IDs: want %q, have %q", want, have)
+	}
+	if want, have := ErrClosed, sub.Err(); !errors.Is(have, want) {
+		t.Errorf("Err: want %v, have %v", want, have)
+	}
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		w.Flush()
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	defer es.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := es.Subscribe(ctx, SubscribeConfig{})
+
+	if err := sub.Err(); err != nil {
+		t.Errorf("Err while running: want nil, have %v", err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Fatal("unexpected event")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events channel wasn't closed")
+	}
+
+	if want, have := context.Canceled, sub.Err(); !errors.Is(have, want) {
+		t.Errorf("Err: want %v, have %v", want, have)
+	}
+	if err := es.Err(); err != nil {
+		t.Errorf("event source should not be closed, but has error %v", err)
+	}
+}
+
+func TestSubscribeOverflow(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		overflow Overflow
+		want     []string
+	}{
+		{OverflowDropNewest, []string{"1", "2"}},
+		{OverflowDropOldest, []string{"4", "5"}},
+	} {
+		t.Run(fmt.Sprint(tt.overflow), func(t *testing.T) {
+			t.Parallel()
+
+			server := testServer(func(w responseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.WriteHeader(200)
+				for i := 1; i <= 5; i++ {
+					fmt.Fprintf(w, "id: %d\ndata: foo\n\n", i)
+				}
+				w.Flush()
+				<-r.Context().Done()
+			})
+			defer server.Close()
+
+			es := New(request(server.URL), time.Millisecond)
+			defer es.Close()
+
+			sub := es.Subscribe(context.Background(), SubscribeConfig{BufferSize: 2, Overflow: tt.overflow})
+
+			deadline := time.Now().Add(time.Second)
+			for sub.Dropped() < 3 {
+				if time.Now().After(deadline) {
+					t.Fatalf("Dropped: want 3, have %d", sub.Dropped())
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			var ids []string
+			for len(ids) < 2 {
+				ids = append(ids, (<-sub.Events()).ID)
+			}
+			if want, have := tt.want, ids; !reflect.DeepEqual(want, have) {
+				t.Errorf("IDs: want %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestSubscribeClose(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(func(w responseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		NewEncoder(w).Encode(Event{Data: []byte("foo")})
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	es := New(request(server.URL), time.Millisecond)
+	sub := es.Subscribe(context.Background(), SubscribeConfig{})
+
+	time.Sleep(20 * time.Millisecond) // blocked delivering the event, with nobody receiving
+	es.Close()
+
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("subscription still running after Close")
+	}
+	if want, have := ErrClosed, sub.Err(); !errors.Is(have, want) {
+		t.Errorf("Err: want %v, have %v", want, have)
+	}
+}
+
+func TestSubscribeGap(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := testServer(func(w responseWriter, _ *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(204)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		for _, id := range []int{1, 2, 5, 6} {
+			fmt.Fprintf(w, "id: %d\ndata: foo\n\n", id)
+		}
+	})
+	defer server.Close()
+
+	es := NewConfig(Config{
+		Request:    request(server.URL),
+		Retry:      time.Millisecond,
+		Sequencing: &Sequencing{Parse: ParseNumericID},
+	})
+
+	var gaps []GapError
+	sub := es.Subscribe(context.Background(), SubscribeConfig{
+		OnGap: func(gap *GapError) { gaps = append(gaps, *gap) },
+	})
+
+	var ids []string
+	for e := range sub.Events() {
+		ids = append(ids, e.ID)
+	}
+
+	if want, have := []string{"1", "2", "5", "6"}, ids; !reflect.DeepEqual(want, have) {
+		t.Errorf("IDs: want %q, have %q", want, have)
+	}
+	if want, have := []GapError{{LastID: "2", NextID: "5", Missing: 2}}, gaps; !reflect.DeepEqual(want, have) {
+		t.Errorf("gaps: want %+v, have %+v", want, have)
+	}
+	if want, have := ErrClosed, sub.Err(); !errors.Is(have, want) {
+		t.Errorf("Err: want %v, have %v", want, have)
+	}
+}